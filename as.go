@@ -0,0 +1,447 @@
+package capture
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// As converts the normalized output of r to a T. It handles the built-in scalar
+// types and types defined on them, time.Duration, types implementing
+// encoding.TextUnmarshaler such as *big.Int, and slices, arrays, maps, structs,
+// empty interfaces and pointers of those.
+//
+// Scalars are converted from the whole output. Composite values are read from
+// JSON or the way fmt prints them with %v, %+v or %#v: [1 2 3], map[a:1 b:2],
+// {Alice 30}, {Name:Alice Age:30} or main.Person{Name:"Alice", Age:30}. The
+// brackets of a slice or array may be left out, as in 1 2 3 or 1,2,3, and empty
+// output is an empty slice.
+// Strings in composite values may be quoted; unquoted, they end at white space,
+// so %v output of strings containing spaces is ambiguous. Slices of strings are
+// read as described for Result.AsSliceString. When some items cannot
+// be converted, As converts the others and returns the error of the first one;
+// scalars out of range are returned clamped to the range of T. Errors are
+// returned as a *ConversionError.
+func As[T any](r Result) (T, error) {
+	var result T
+	err := decodeString(r.normalized(), reflect.ValueOf(&result).Elem())
+	return result, conversionError[T](r, err)
+}
+
+// Decode converts the normalized output of o into the value v points to, like As.
+func (o Result) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("capture: Decode of non-pointer %T", v)
+	}
+
+	err := decodeString(o.normalized(), rv.Elem())
+	if err != nil {
+		return &ConversionError{Value: o.Value, Type: rv.Elem().Type(), Err: err}
+	}
+	return nil
+}
+
+// MustAs is like As but panics if the output cannot be converted.
+func MustAs[T any](r Result) T {
+	result, err := As[T](r)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// Parse converts s to a T like As converts captured output, trimming the
+// surrounding white space first.
+func Parse[T any](s string) (T, error) {
+	return As[T](Result{Value: s})
+}
+
+// decodeString decodes s into v, parsing it first if v holds a composite value.
+// A slice or array may also be printed without brackets, its items separated by
+// white space or commas, as in 1 2 3, or be empty output.
+func decodeString(s string, v reflect.Value) error {
+	err := decodeText(s, v)
+	if err != nil && unbracketed(s, v.Type()) {
+		list := reflect.New(v.Type()).Elem()
+		if decodeText("["+s+"]", list) == nil {
+			v.Set(list)
+			return nil
+		}
+	}
+	return err
+}
+
+// unbracketed reports whether s may be the items of a slice or array of type t
+// printed without brackets. Strings must be quoted, as any output would read as
+// a list of words otherwise.
+func unbracketed(s string, t reflect.Type) bool {
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return false
+	}
+	if isStringSlice(t) && s != "" && s[0] != '"' {
+		return false
+	}
+	return !strings.HasPrefix(s, "[") && !strings.HasSuffix(s, "]")
+}
+
+// decodeText decodes s into v like decodeString, without the fallback for
+// slices printed without brackets.
+func decodeText(s string, v reflect.Value) error {
+	if isStringSlice(v.Type()) && !goSyntax(s) {
+		items, err := decodeStrings(s)
+		if items != nil {
+			v.Set(reflect.MakeSlice(v.Type(), len(items), len(items)))
+			for i, item := range items {
+				v.Index(i).SetString(item)
+			}
+		}
+		return err
+	}
+	if !composite(v.Type()) {
+		return decode(node{kind: scalarNode, text: s}, v)
+	}
+
+	n, err := parse(s)
+	if err != nil {
+		if v.Kind() == reflect.Interface {
+			// Output that is not a composite value is a string.
+			return decode(node{kind: scalarNode, text: s}, v)
+		}
+		return err
+	}
+	return decode(n, v)
+}
+
+// composite reports whether values of t are parsed from a list, map or struct.
+func composite(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == durationType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct, reflect.Interface:
+		return true
+	default:
+		return false
+	}
+}
+
+// decode decodes n into v, which must be settable. It converts as much of n as
+// it can, returning the first error.
+func decode(n node, v reflect.Value) error {
+	t := v.Type()
+	if n.isNil() {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			v.SetZero()
+			return nil
+		}
+	}
+
+	switch {
+	case t.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return decode(n, v.Elem())
+	case t == durationType:
+		text, err := n.scalar(t)
+		if err != nil {
+			return err
+		}
+		d, err := time.ParseDuration(text)
+		v.SetInt(int64(d))
+		return err
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		if n.kind != scalarNode && n.kind != quotedNode {
+			return fmt.Errorf("cannot convert %v to %v", n.kind, t)
+		}
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(n.text))
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if n.kind != scalarNode && n.kind != quotedNode {
+			return fmt.Errorf("cannot convert %v to %v", n.kind, t)
+		}
+		v.SetString(n.text)
+		return nil
+	case reflect.Bool:
+		text, err := n.scalar(t)
+		if err != nil {
+			return err
+		}
+		b, err := strconv.ParseBool(text)
+		v.SetBool(b)
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		text, err := n.scalar(t)
+		if err != nil {
+			return err
+		}
+		i, err := strconv.ParseInt(text, 10, t.Bits())
+		v.SetInt(i)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		text, err := n.scalar(t)
+		if err != nil {
+			return err
+		}
+		base := 10
+		if strings.HasPrefix(text, "0x") {
+			base = 0 // %#v prints unsigned integers in hexadecimal.
+		}
+		u, err := strconv.ParseUint(text, base, t.Bits())
+		v.SetUint(u)
+		return err
+	case reflect.Float32, reflect.Float64:
+		text, err := n.scalar(t)
+		if err != nil {
+			return err
+		}
+		f, err := strconv.ParseFloat(text, t.Bits())
+		v.SetFloat(f)
+		return err
+	case reflect.Complex64, reflect.Complex128:
+		text, err := n.scalar(t)
+		if err != nil {
+			return err
+		}
+		c, err := strconv.ParseComplex(text, t.Bits())
+		v.SetComplex(c)
+		return err
+	case reflect.Slice:
+		if n.kind != listNode && !n.empty() {
+			return fmt.Errorf("cannot convert %v to %v", n.kind, t)
+		}
+		v.Set(reflect.MakeSlice(t, len(n.items), len(n.items)))
+		return decodeItems(n.items, v)
+	case reflect.Array:
+		if n.kind != listNode && !n.empty() {
+			return fmt.Errorf("cannot convert %v to %v", n.kind, t)
+		}
+		if len(n.items) != t.Len() {
+			return fmt.Errorf("cannot convert list of %d items to %v", len(n.items), t)
+		}
+		return decodeItems(n.items, v)
+	case reflect.Map:
+		if n.kind != mapNode && !n.empty() {
+			return fmt.Errorf("cannot convert %v to %v", n.kind, t)
+		}
+		v.Set(reflect.MakeMapWithSize(t, len(n.items)))
+		var first error
+		for i := range n.items {
+			key := reflect.New(t.Key()).Elem()
+			if err := decode(n.keys[i], key); err != nil && first == nil {
+				first = fmt.Errorf("key %q: %w", n.keys[i].text, err)
+			}
+			value := reflect.New(t.Elem()).Elem()
+			if err := decode(n.items[i], value); err != nil && first == nil {
+				first = fmt.Errorf("value of %q: %w", n.keys[i].text, err)
+			}
+			v.SetMapIndex(key, value)
+		}
+		return first
+	case reflect.Struct:
+		return decodeStruct(n, v)
+	case reflect.Interface:
+		if t.NumMethod() > 0 {
+			return fmt.Errorf("unsupported type %v", t)
+		}
+		value, err := decodeAny(n)
+		if value != nil {
+			v.Set(reflect.ValueOf(value))
+		}
+		return err
+	default:
+		return fmt.Errorf("unsupported type %v", t)
+	}
+}
+
+// decodeStruct decodes n into the struct v, from positional fields as %v prints
+// them or from named fields as %+v, %#v and JSON print them. Embedded structs are
+// decoded by their type name, setting their exported fields even when the type is
+// unexported, as encoding/json does. Output for other unexported fields is
+// reported as an error once the rest is decoded.
+func decodeStruct(n node, v reflect.Value) error {
+	t := v.Type()
+	var first error
+	switch {
+	case n.kind == listNode && len(n.items) > 0:
+		if len(n.items) != t.NumField() {
+			return fmt.Errorf("cannot convert %d fields to %v with %d fields", len(n.items), t, t.NumField())
+		}
+		for i, item := range n.items {
+			if !settable(t.Field(i)) {
+				if first == nil {
+					first = fmt.Errorf("cannot set unexported field %s of %v", t.Field(i).Name, t)
+				}
+				continue
+			}
+			if err := decode(item, v.Field(i)); err != nil && first == nil {
+				first = fmt.Errorf("field %s: %w", t.Field(i).Name, err)
+			}
+		}
+	case n.kind == mapNode || n.empty():
+		for i, key := range n.keys {
+			field, ok := fieldByName(t, key.text)
+			if !ok {
+				if first == nil {
+					first = fmt.Errorf("%v has no field %s", t, key.text)
+				}
+				continue
+			}
+			if !settable(field) {
+				if first == nil {
+					first = fmt.Errorf("cannot set unexported field %s of %v", field.Name, t)
+				}
+				continue
+			}
+			fv := fieldValue(v, field.Index)
+			if !fv.IsValid() {
+				if first == nil {
+					first = fmt.Errorf("cannot set field %s of %v", field.Name, t)
+				}
+				continue
+			}
+			if err := decode(n.items[i], fv); err != nil && first == nil {
+				first = fmt.Errorf("field %s: %w", field.Name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("cannot convert %v to %v", n.kind, t)
+	}
+	return first
+}
+
+// fieldByName returns the field of t called name, or named so by its json tag,
+// matching exported fields case-insensitively if no field matches exactly.
+func fieldByName(t reflect.Type, name string) (reflect.StructField, bool) {
+	var folded reflect.StructField
+	found := false
+	for _, field := range reflect.VisibleFields(t) {
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Name == name || tag == name {
+			return field, true
+		}
+		if !found && field.IsExported() && strings.EqualFold(field.Name, name) {
+			folded, found = field, true
+		}
+	}
+	return folded, found
+}
+
+// settable reports whether decoding can set field: an exported field, or an
+// embedded struct, whose exported fields can be set even if its type is unexported.
+func settable(field reflect.StructField) bool {
+	return field.IsExported() || field.Anonymous && field.Type.Kind() == reflect.Struct
+}
+
+// fieldValue returns the field of v at index, allocating the embedded structs
+// it is promoted through when they are nil pointers. It returns the zero Value
+// if such a pointer cannot be set.
+func fieldValue(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// decodeAny decodes n into the value an empty interface holds: a string, bool,
+// int, float64 or complex128 for a scalar, []any for a list and map[string]any
+// for a map or struct.
+func decodeAny(n node) (any, error) {
+	switch {
+	case n.isNil():
+		return nil, nil
+	case n.kind == quotedNode:
+		return n.text, nil
+	case n.kind == listNode:
+		items := make([]any, len(n.items))
+		var first error
+		for i, item := range n.items {
+			var err error
+			if items[i], err = decodeAny(item); err != nil && first == nil {
+				first = fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		return items, first
+	case n.kind == mapNode:
+		values := make(map[string]any, len(n.items))
+		var first error
+		for i, item := range n.items {
+			var err error
+			if values[n.keys[i].text], err = decodeAny(item); err != nil && first == nil {
+				first = fmt.Errorf("value of %q: %w", n.keys[i].text, err)
+			}
+		}
+		return values, first
+	}
+
+	if i, err := strconv.Atoi(n.text); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(n.text, 64); err == nil {
+		return f, nil
+	}
+	if c, err := strconv.ParseComplex(n.text, 128); err == nil {
+		return c, nil
+	}
+	if n.text == "true" || n.text == "false" {
+		return n.text == "true", nil
+	}
+	return n.text, nil
+}
+
+// decodeItems decodes the items of a list into the elements of the slice or array v.
+func decodeItems(items []node, v reflect.Value) error {
+	var first error
+	for i, item := range items {
+		if err := decode(item, v.Index(i)); err != nil && first == nil {
+			first = fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	return first
+}
+
+// isNil reports whether n is a nil value: []int(nil) as %#v prints it, or
+// <nil> as %v does.
+func (n node) isNil() bool {
+	return n.kind == nilNode || n.kind == scalarNode && n.text == "<nil>"
+}
+
+// empty reports whether n is a list or map without items, which %#v prints the
+// same way for both, []int{} and map[string]int{}.
+func (n node) empty() bool {
+	return (n.kind == listNode || n.kind == mapNode) && len(n.items) == 0
+}
+
+// scalar returns the text of a bare word, which is what a value of the scalar
+// type t is read from.
+func (n node) scalar(t reflect.Type) (string, error) {
+	if n.kind != scalarNode {
+		return "", fmt.Errorf("cannot convert %v to %v", n.kind, t)
+	}
+	return n.text, nil
+}
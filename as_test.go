@@ -0,0 +1,144 @@
+package capture_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hireza/go-capture"
+)
+
+type celsius float64
+
+func TestAs(t *testing.T) {
+	tests := []struct {
+		name  string
+		print any
+		as    func(capture.Result) (any, error)
+		want  any
+	}{
+		{name: "int", print: 42, as: as[int], want: 42},
+		{name: "duration", print: 90 * time.Second, as: as[time.Duration], want: 90 * time.Second},
+		{name: "named", print: celsius(21.5), as: as[celsius], want: celsius(21.5)},
+		{name: "big.Int", print: new(big.Int).Lsh(big.NewInt(1), 100), as: asString[*big.Int], want: "1267650600228229401496703205376"},
+		{name: "TextUnmarshaler", print: netip.MustParseAddr("10.0.0.1"), as: as[netip.Addr], want: netip.MustParseAddr("10.0.0.1")},
+		{name: "pointer", print: 7, as: asElem[*int], want: 7},
+		{name: "slice", print: []int{1, 2, 3}, as: as[[]int], want: []int{1, 2, 3}},
+		{name: "nested slice", print: [][]int{{1, 2}, {3}}, as: as[[][]int], want: [][]int{{1, 2}, {3}}},
+		{name: "array", print: [2]bool{true, false}, as: as[[2]bool], want: [2]bool{true, false}},
+		{name: "durations", print: []time.Duration{time.Second, time.Minute}, as: as[[]time.Duration], want: []time.Duration{time.Second, time.Minute}},
+		{name: "map", print: map[string]int{"a": 1, "b": 2}, as: as[map[string]int], want: map[string]int{"a": 1, "b": 2}},
+		{name: "map of slices", print: map[int][]string{1: {"x"}, 2: {}}, as: as[map[int][]string], want: map[int][]string{1: {"x"}, 2: {}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := capture.Stdout(func() {
+				fmt.Println(test.print)
+			})
+
+			got, err := test.as(output)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Expected %v but got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func as[T any](r capture.Result) (any, error) {
+	return capture.As[T](r)
+}
+
+func asString[T any](r capture.Result) (any, error) {
+	v, err := capture.As[T](r)
+	return fmt.Sprint(v), err
+}
+
+func asElem[T any](r capture.Result) (any, error) {
+	v, err := capture.As[T](r)
+	return reflect.ValueOf(v).Elem().Interface(), err
+}
+
+func TestAsJSON(t *testing.T) {
+	got, err := capture.Parse[map[string][]float64](`{"a": [1.5, 2], "b": []}`)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	want := map[string][]float64{"a": {1.5, 2}, "b": {}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v but got %v", want, got)
+	}
+}
+
+func TestAsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		as    func(capture.Result) (any, error)
+	}{
+		{name: "array length", input: "[1 2 3]", as: as[[2]int]},
+		{name: "unterminated", input: "[1 2", as: as[[]int]},
+		{name: "trailing", input: "[1] 2", as: as[[]int]},
+		{name: "map key", input: "map[a:1]", as: as[map[int]int]},
+		{name: "list to map", input: "[1 2]", as: as[map[int]int]},
+		{name: "duration", input: "soon", as: as[time.Duration]},
+		{name: "unsupported", input: "x", as: as[chan int]},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.as(capture.Result{Value: test.input})
+
+			var conversionErr *capture.ConversionError
+			if !errors.As(err, &conversionErr) {
+				t.Errorf("Expected a ConversionError but got %v", err)
+			}
+		})
+	}
+}
+
+func TestMustAs(t *testing.T) {
+	if got := capture.MustAs[uint16](capture.Result{Value: "65535\n"}); got != 65535 {
+		t.Errorf("Expected 65535 but got %d", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected MustAs to panic")
+		}
+	}()
+	capture.MustAs[uint16](capture.Result{Value: "65536"})
+}
+
+func TestAsComplexSlices(t *testing.T) {
+	values := []complex128{complex(1, 2), complex(-0.5, 1e-9), complex(math.Inf(1), math.NaN())}
+
+	output := capture.Stdout(func() {
+		fmt.Println(values)
+		fmt.Printf("%#v\n", values[:2])
+	})
+
+	lines := strings.Split(strings.TrimSpace(output.Value), "\n")
+	got, err := capture.Parse[[]complex128](lines[0])
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(got) != 3 || got[0] != values[0] || got[1] != values[1] || !math.IsInf(real(got[2]), 1) || !math.IsNaN(imag(got[2])) {
+		t.Errorf("Expected %v but got %v", values, got)
+	}
+
+	got64, err := capture.Parse[[]complex64](lines[1])
+	if err != nil || !reflect.DeepEqual(got64, []complex64{complex(1, 2), complex(-0.5, 1e-9)}) {
+		t.Errorf("Expected %v but got %v, %v", values[:2], got64, err)
+	}
+}
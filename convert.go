@@ -0,0 +1,79 @@
+package capture
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Normalizer prepares the captured output for the As conversions of a Result.
+type Normalizer func(string) string
+
+var (
+	// TrimSpace removes the leading and trailing white space, such as the newline
+	// fmt.Println ends with. It is the default.
+	TrimSpace Normalizer = strings.TrimSpace
+
+	// TrimNewline removes a single trailing "\n" or "\r\n".
+	TrimNewline Normalizer = func(s string) string {
+		s = strings.TrimSuffix(s, "\n")
+		return strings.TrimSuffix(s, "\r")
+	}
+
+	// Strict leaves the output as it is, so any surrounding white space makes a
+	// conversion fail.
+	Strict Normalizer = func(s string) string {
+		return s
+	}
+)
+
+// WithNormalizer sets the Normalizer used by the As conversions of the results
+// captured with the Capture. The default is TrimSpace.
+func WithNormalizer(n Normalizer) Option {
+	return func(c *Capture) {
+		c.normalize = n
+	}
+}
+
+// WithNormalizer returns a copy of the Result converting with n.
+func (o Result) WithNormalizer(n Normalizer) Result {
+	o.normalize = n
+	return o
+}
+
+// normalized returns the value the As conversions convert.
+func (o Result) normalized() string {
+	if o.normalize == nil {
+		return TrimSpace(o.Value)
+	}
+	return o.normalize(o.Value)
+}
+
+// ConversionError is returned by the As conversions of a Result when the output
+// cannot be converted.
+type ConversionError struct {
+	// Value is the captured output, before it was normalized.
+	Value string
+
+	// Type is the type the output was converted to.
+	Type reflect.Type
+
+	// Err is the error of the underlying conversion.
+	Err error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("capture: convert %q to %v: %v", e.Value, e.Type, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// conversionError wraps err, if set, in a ConversionError for converting o to T.
+func conversionError[T any](o Result, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ConversionError{Value: o.Value, Type: reflect.TypeFor[T](), Err: err}
+}
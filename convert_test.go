@@ -0,0 +1,80 @@
+package capture_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/hireza/go-capture"
+)
+
+func TestConversionNormalized(t *testing.T) {
+	output := capture.Stdout(func() {
+		fmt.Println(42)
+	})
+
+	got, err := output.AsInt()
+	if err != nil || got != 42 {
+		t.Errorf("Expected 42 but got %d, %v", got, err)
+	}
+
+	f, err := capture.Result{Value: " 3.5\r\n"}.AsFloat64()
+	if err != nil || f != 3.5 {
+		t.Errorf("Expected 3.5 but got %v, %v", f, err)
+	}
+
+	b, err := capture.Result{Value: "true\n"}.AsBool()
+	if err != nil || !b {
+		t.Errorf("Expected true but got %v, %v", b, err)
+	}
+}
+
+func TestWithNormalizer(t *testing.T) {
+	tests := []struct {
+		name       string
+		normalizer capture.Normalizer
+		input      string
+		wantErr    bool
+	}{
+		{name: "TrimSpace", normalizer: capture.TrimSpace, input: " 42\n", wantErr: false},
+		{name: "TrimNewline", normalizer: capture.TrimNewline, input: "42\r\n", wantErr: false},
+		{name: "TrimNewline leading space", normalizer: capture.TrimNewline, input: " 42\n", wantErr: true},
+		{name: "Strict", normalizer: capture.Strict, input: "42", wantErr: false},
+		{name: "Strict newline", normalizer: capture.Strict, input: "42\n", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := capture.New(capture.WithNormalizer(test.normalizer)).Stdout(func() {
+				fmt.Print(test.input)
+			})
+
+			_, err := output.AsInt()
+			if (err != nil) != test.wantErr {
+				t.Errorf("AsInt() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestConversionError(t *testing.T) {
+	_, err := capture.Result{Value: "abc\n"}.AsInt8()
+
+	var conversionErr *capture.ConversionError
+	if !errors.As(err, &conversionErr) {
+		t.Fatalf("Expected a ConversionError but got %v", err)
+	}
+	if conversionErr.Value != "abc\n" || conversionErr.Type != reflect.TypeFor[int8]() {
+		t.Errorf("Expected %q to int8 but got %q to %v", "abc\n", conversionErr.Value, conversionErr.Type)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Expected the error to wrap strconv.ErrSyntax")
+	}
+
+	expected := `capture: convert "abc\n" to int8: strconv.ParseInt: parsing "abc": invalid syntax`
+	if err.Error() != expected {
+		t.Errorf("Expected %q but got %q", expected, err.Error())
+	}
+}
@@ -0,0 +1,146 @@
+package capture_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hireza/go-capture"
+)
+
+type person struct {
+	Name string
+	Age  int
+	Tags []string
+}
+
+type point struct {
+	X, Y int
+}
+
+// label embeds an unexported struct, decoded by its type name.
+type label struct {
+	point
+	Text string
+}
+
+type team struct {
+	Lead    person
+	Members map[string]person
+	Scores  [][]float64
+}
+
+func TestResultDecode(t *testing.T) {
+	alice := person{Name: "Alice", Age: 30, Tags: []string{"admin", "ops"}}
+	values := []any{
+		alice,
+		&alice,
+		[]person{alice, {Name: "Bob"}},
+		map[string]int{"a": 1, "b": 2},
+		[][]int{{1, 2}, {}, {3}},
+		team{Lead: alice, Members: map[string]person{"bob": {Name: "Bob", Age: 25}}, Scores: [][]float64{{1.5}, nil}},
+		[]byte("hi"),
+		map[int][]uint{1: {2}, 3: nil},
+		[]string{"admin", "ops"},
+		[]string(nil),
+		label{point: point{X: 1, Y: 2}, Text: "origin"},
+		[]label{{Text: "a"}, {point: point{X: 3}, Text: "b"}},
+	}
+
+	for _, verb := range []string{"%v", "%+v", "%#v"} {
+		for _, value := range values {
+			t.Run(fmt.Sprintf("%s %T", verb, value), func(t *testing.T) {
+				output := capture.Stdout(func() {
+					fmt.Printf(verb+"\n", value)
+				})
+
+				got := reflect.New(reflect.TypeOf(value))
+				if err := output.Decode(got.Interface()); err != nil {
+					t.Fatalf("Expected no error decoding %q but got %v", output.Value, err)
+				}
+				if !equal(got.Elem().Interface(), value) {
+					t.Errorf("Expected %#v but got %#v", value, got.Elem().Interface())
+				}
+			})
+		}
+	}
+}
+
+func TestResultDecodeGoSyntaxStrings(t *testing.T) {
+	// %#v quotes the items, so unlike %v it keeps items with spaces apart.
+	values := [][]string{{"a b", "c"}, {""}, nil}
+
+	for _, value := range values {
+		t.Run(fmt.Sprintf("%#v", value), func(t *testing.T) {
+			s := fmt.Sprintf("%#v", value)
+			got, err := capture.Parse[[]string](s)
+			if err != nil {
+				t.Fatalf("Expected no error parsing %q but got %v", s, err)
+			}
+			if !reflect.DeepEqual(got, value) {
+				t.Errorf("Expected %#v but got %#v", value, got)
+			}
+		})
+	}
+}
+
+// equal is like reflect.DeepEqual but does not distinguish nil and empty
+// slices and maps, which fmt prints the same way.
+func equal(a, b any) bool {
+	return fmt.Sprintf("%#v", a) == fmt.Sprintf("%#v", b) ||
+		fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b) && reflect.TypeOf(a) == reflect.TypeOf(b)
+}
+
+func TestResultDecodeAny(t *testing.T) {
+	output := capture.Stdout(func() {
+		fmt.Println(map[string]any{"n": 1, "f": 1.5, "list": []any{"x", true}})
+	})
+
+	var got any
+	if err := output.Decode(&got); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	want := map[string]any{"n": 1, "f": 1.5, "list": []any{"x", true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v but got %v", want, got)
+	}
+}
+
+func TestResultDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		v     any
+	}{
+		{name: "non-pointer", input: "1", v: person{}},
+		{name: "field count", input: "{Alice 30}", v: &person{}},
+		{name: "unknown field", input: "{Name:Alice Height:180}", v: &person{}},
+		{name: "mixed", input: "{Alice Age:30}", v: &person{}},
+		{name: "field type", input: "{Name:Alice Age:old}", v: &person{}},
+		{name: "unexported field", input: "{Alice 30 secret}", v: &struct {
+			Name   string
+			Age    int
+			secret string
+		}{}},
+		{name: "unexported named field", input: "{Name:Alice secret:x}", v: &struct {
+			Name   string
+			secret string
+		}{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := capture.Result{Value: test.input}.Decode(test.v)
+			if err == nil {
+				t.Errorf("Expected an error decoding %q", test.input)
+			}
+		})
+	}
+
+	var conversionErr *capture.ConversionError
+	if err := (capture.Result{Value: "{Name:Alice Age:old}"}).Decode(&person{}); !errors.As(err, &conversionErr) {
+		t.Errorf("Expected a ConversionError but got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package capture
+
+import "errors"
+
+// Errors reported by the error-returning capture functions. They are wrapped
+// together with the underlying error, so use errors.Is to test for them.
+var (
+	// ErrPipeCreate is returned when the pipe or temporary file a stream is
+	// redirected into cannot be created.
+	ErrPipeCreate = errors.New("capture: create pipe")
+
+	// ErrRedirect is returned when a stream cannot be redirected.
+	ErrRedirect = errors.New("capture: redirect stream")
+
+	// ErrRead is returned when reading the captured output fails.
+	ErrRead = errors.New("capture: read output")
+
+	// ErrRestore is returned when a redirected stream cannot be restored.
+	ErrRestore = errors.New("capture: restore stream")
+
+	// ErrOutputTooLarge is returned when the output exceeds the limit set with
+	// WithMaxBytes and the overflow policy is FailOnOverflow.
+	ErrOutputTooLarge = errors.New("capture: output too large")
+
+	// ErrAmbiguous is returned by conversions of output that could have been
+	// printed from different values.
+	ErrAmbiguous = errors.New("capture: ambiguous output")
+
+	// ErrGoexit is returned when a function captured with a deadline, which
+	// runs on a goroutine of its own, exits that goroutine with runtime.Goexit,
+	// as t.FailNow, t.Fatal and t.SkipNow do, instead of returning.
+	ErrGoexit = errors.New("capture: captured function exited its goroutine")
+
+	// ErrFinished is returned by an Interaction waiting for output after the
+	// captured function has returned.
+	ErrFinished = errors.New("capture: captured function has returned")
+)
@@ -0,0 +1,65 @@
+package capture_test
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/hireza/go-capture"
+)
+
+// withFileLimit runs f with the soft limit on open files lowered, so that no new
+// file descriptor can be allocated.
+func withFileLimit(t *testing.T, f func()) {
+	t.Helper()
+
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		t.Skipf("Getrlimit() error = %v", err)
+	}
+
+	lowered := limit
+	lowered.Cur = 3
+	if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &lowered); err != nil {
+		t.Skipf("Setrlimit() error = %v", err)
+	}
+	defer func() {
+		if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+			t.Fatalf("Setrlimit() error = %v", err)
+		}
+	}()
+
+	f()
+}
+
+func TestCaptureErrPipeCreate(t *testing.T) {
+	called := false
+	var err error
+	withFileLimit(t, func() {
+		_, err = capture.OutputE(func() {
+			called = true
+		})
+	})
+
+	if !errors.Is(err, capture.ErrPipeCreate) {
+		t.Errorf("OutputE() error = %v, want %v", err, capture.ErrPipeCreate)
+	}
+	if called {
+		t.Error("Expected f not to be called when the pipe cannot be created")
+	}
+}
+
+func TestCaptureErrPipeCreatePanics(t *testing.T) {
+	var recovered any
+	withFileLimit(t, func() {
+		defer func() {
+			recovered = recover()
+		}()
+		capture.Stdout(func() {})
+	})
+
+	err, ok := recovered.(error)
+	if !ok || !errors.Is(err, capture.ErrPipeCreate) {
+		t.Errorf("Stdout() panicked with %v, want %v", recovered, capture.ErrPipeCreate)
+	}
+}
@@ -0,0 +1,52 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"time"
+)
+
+// Command runs cmd and captures its output the same way in-process captures are
+// collected, so the Result conversions work on the output of external programs.
+// The Result holds stdout, stderr, their combination, the exit code and how long
+// the command ran. A non-zero exit status is reported in Result.ExitCode rather
+// than as an error; the error is only set when the command could not be run.
+// The Stdout and Stderr of cmd must not be set.
+func Command(cmd *exec.Cmd) (Result, error) {
+	if cmd.Stdout != nil {
+		return Result{}, errors.New("capture: Stdout already set")
+	}
+	if cmd.Stderr != nil {
+		return Result{}, errors.New("capture: Stderr already set")
+	}
+
+	col := newCollector(limit{}, false)
+	cmd.Stdout = streamWriter{c: col, s: StreamStdout}
+	cmd.Stderr = streamWriter{c: col, s: StreamStderr}
+
+	start := time.Now()
+	err := cmd.Run()
+	duration := time.Since(start)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		err = nil
+	}
+
+	result := col.result()
+	result.ExitCode = cmd.ProcessState.ExitCode()
+	result.Duration = duration
+	return result, err
+}
+
+// Exec runs the named program with the given arguments and captures its output
+// like Command. If ctx is done before the program exits, the program is killed
+// and the output captured so far is returned together with the context's error.
+func Exec(ctx context.Context, name string, args ...string) (Result, error) {
+	result, err := Command(exec.CommandContext(ctx, name, args...))
+	if err == nil {
+		err = ctx.Err()
+	}
+	return result, err
+}
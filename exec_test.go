@@ -0,0 +1,93 @@
+package capture_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hireza/go-capture"
+)
+
+func lookPath(t *testing.T, name string) {
+	t.Helper()
+	if _, err := exec.LookPath(name); err != nil {
+		t.Skipf("%s not available", name)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	lookPath(t, "sh")
+
+	output, err := capture.Command(exec.Command("sh", "-c", "echo '[1 2 3]'; echo oops >&2; exit 4"))
+	if err != nil {
+		t.Fatalf("Command() error = %v", err)
+	}
+
+	if output.ExitCode != 4 {
+		t.Errorf("Expected exit code 4, but got %d", output.ExitCode)
+	}
+	if output.Stdout != "[1 2 3]\n" {
+		t.Errorf("Expected stdout to be %q, but got %q", "[1 2 3]\n", output.Stdout)
+	}
+	if output.Stderr != "oops\n" {
+		t.Errorf("Expected stderr to be %q, but got %q", "oops\n", output.Stderr)
+	}
+	if output.Duration <= 0 {
+		t.Errorf("Expected a positive duration, but got %v", output.Duration)
+	}
+
+	got, err := capture.Result{Value: output.Stdout}.AsSliceInt()
+	if err != nil || !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("AsSliceInt() got = %v, %v", got, err)
+	}
+}
+
+func TestCommandStdoutAlreadySet(t *testing.T) {
+	cmd := exec.Command("true")
+	cmd.Stdout = &bytes.Buffer{}
+
+	if _, err := capture.Command(cmd); err == nil {
+		t.Error("Expected an error when Stdout is already set")
+	}
+}
+
+func TestCommandNotFound(t *testing.T) {
+	output, err := capture.Command(exec.Command("go-capture-does-not-exist"))
+	if err == nil {
+		t.Error("Expected an error for a missing program")
+	}
+	if output.ExitCode != -1 {
+		t.Errorf("Expected exit code -1, but got %d", output.ExitCode)
+	}
+}
+
+func TestExec(t *testing.T) {
+	lookPath(t, "echo")
+
+	output, err := capture.Exec(context.Background(), "echo", "Hello, exec!")
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if output.AsString() != "Hello, exec!\n" || output.ExitCode != 0 {
+		t.Errorf("Expected %q with exit code 0, but got %q with %d", "Hello, exec!\n", output.AsString(), output.ExitCode)
+	}
+}
+
+func TestExecContextTimeout(t *testing.T) {
+	lookPath(t, "sh")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	output, err := capture.Exec(ctx, "sh", "-c", "echo started; exec sleep 5")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Exec() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if output.Stdout != "started\n" {
+		t.Errorf("Expected partial output %q, but got %q", "started\n", output.Stdout)
+	}
+}
@@ -0,0 +1,47 @@
+package capture
+
+import (
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// exitEnv selects the test a re-executed test binary runs the captured function for.
+const exitEnv = "GO_CAPTURE_EXIT_TEST"
+
+// Exit captures the output of f in a subprocess, so f may call os.Exit without
+// ending the test binary. The current test binary is re-executed to run only the
+// calling test, and when that test reaches Exit again it runs f and exits. The
+// Result holds stdout, stderr, their combination and the exit code, which is 0
+// when f returns normally and 2 when it panics.
+//
+// Any code in the test before the call to Exit runs in the subprocess too, so
+// call Exit at most once per test, using subtests for several calls.
+func Exit(t testing.TB, f func()) Result {
+	t.Helper()
+
+	if os.Getenv(exitEnv) == t.Name() {
+		f()
+		os.Exit(0)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run="+runPattern(t.Name()))
+	cmd.Env = append(os.Environ(), exitEnv+"="+t.Name())
+
+	result, err := Command(cmd)
+	if err != nil {
+		t.Fatalf("capture: running %s in a subprocess: %v", t.Name(), err)
+	}
+	return result
+}
+
+// runPattern returns the -test.run pattern that matches only the test name.
+func runPattern(name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = "^" + regexp.QuoteMeta(part) + "$"
+	}
+	return strings.Join(parts, "/")
+}
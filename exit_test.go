@@ -0,0 +1,75 @@
+package capture_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hireza/go-capture"
+)
+
+func TestExit(t *testing.T) {
+	output := capture.Exit(t, func() {
+		fmt.Println("Hello, stdout!")
+		fmt.Fprintln(os.Stderr, "Hello, stderr!")
+		os.Exit(3)
+	})
+
+	if output.ExitCode != 3 {
+		t.Errorf("Expected exit code 3, but got %d", output.ExitCode)
+	}
+	if output.Stdout != "Hello, stdout!\n" {
+		t.Errorf("Expected stdout to be %q, but got %q", "Hello, stdout!\n", output.Stdout)
+	}
+	if output.Stderr != "Hello, stderr!\n" {
+		t.Errorf("Expected stderr to be %q, but got %q", "Hello, stderr!\n", output.Stderr)
+	}
+	if len(output.Value) != len(output.Stdout)+len(output.Stderr) {
+		t.Errorf("Expected combined output to hold both streams, but got %q", output.Value)
+	}
+}
+
+func TestExitReturns(t *testing.T) {
+	output := capture.Exit(t, func() {
+		fmt.Print("done")
+	})
+
+	if output.ExitCode != 0 {
+		t.Errorf("Expected exit code 0, but got %d", output.ExitCode)
+	}
+	if output.Value != "done" {
+		t.Errorf("Expected output to be %q, but got %q", "done", output.Value)
+	}
+}
+
+func TestExitPanics(t *testing.T) {
+	output := capture.Exit(t, func() {
+		panic("boom")
+	})
+
+	if output.ExitCode != 2 {
+		t.Errorf("Expected exit code 2, but got %d", output.ExitCode)
+	}
+	if !strings.Contains(output.Stderr, "panic: boom") {
+		t.Errorf("Expected stderr to contain the panic, but got %q", output.Stderr)
+	}
+}
+
+func TestExitSubtests(t *testing.T) {
+	for _, code := range []int{0, 1, 7} {
+		t.Run(fmt.Sprintf("exit code %d", code), func(t *testing.T) {
+			output := capture.Exit(t, func() {
+				fmt.Print(code)
+				os.Exit(code)
+			})
+
+			if output.ExitCode != code {
+				t.Errorf("Expected exit code %d, but got %d", code, output.ExitCode)
+			}
+			if output.Value != fmt.Sprint(code) {
+				t.Errorf("Expected output to be %q, but got %q", fmt.Sprint(code), output.Value)
+			}
+		})
+	}
+}
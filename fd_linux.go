@@ -0,0 +1,25 @@
+//go:build linux
+
+package capture
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// dupFD returns a duplicate of the file descriptor fd, which keeps writing where
+// fd does now.
+func dupFD(fd int) (*os.File, error) {
+	dup, err := syscall.Dup(fd)
+	if err != nil {
+		return nil, os.NewSyscallError("dup", err)
+	}
+	syscall.CloseOnExec(dup)
+	return os.NewFile(uintptr(dup), "/dev/fd/"+strconv.Itoa(fd)), nil
+}
+
+// setFD points the file descriptor fd at f.
+func setFD(fd int, f *os.File) error {
+	return os.NewSyscallError("dup3", syscall.Dup3(int(f.Fd()), fd, 0))
+}
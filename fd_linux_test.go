@@ -0,0 +1,150 @@
+//go:build linux
+
+package capture_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hireza/go-capture"
+)
+
+func TestCaptureDupFDStdout(t *testing.T) {
+	output := capture.UseMethod(capture.DupFD).Stdout(func() {
+		fmt.Println("Hello, os.Stdout!")
+		_, _ = syscall.Write(1, []byte("Hello, fd 1!\n"))
+	})
+
+	expected := "Hello, os.Stdout!\nHello, fd 1!\n"
+	if output.Value != expected {
+		t.Errorf("Expected output to be %q, but got %q", expected, output.Value)
+	}
+}
+
+func TestCaptureDupFDStderr(t *testing.T) {
+	output := capture.UseMethod(capture.DupFD).Stderr(func() {
+		fmt.Fprintln(os.Stderr, "Hello, os.Stderr!")
+		println("Hello, runtime!")
+	})
+
+	expected := "Hello, os.Stderr!\nHello, runtime!\n"
+	if output.Value != expected {
+		t.Errorf("Expected output to be %q, but got %q", expected, output.Value)
+	}
+}
+
+func TestCaptureDupFDChildProcess(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	output := capture.UseMethod(capture.DupFD).Output(func() {
+		cmd := exec.Command("sh", "-c", "echo out; echo err >&2")
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	})
+
+	expected := "out\nerr\n"
+	if output.Value != expected {
+		t.Errorf("Expected output to be %q, but got %q", expected, output.Value)
+	}
+}
+
+func TestCaptureDupFDRestoresDescriptors(t *testing.T) {
+	var before, after syscall.Stat_t
+	if err := syscall.Fstat(1, &before); err != nil {
+		t.Fatal(err)
+	}
+
+	capture.UseMethod(capture.DupFD).Stdout(func() {
+		fmt.Println("discarded")
+	})
+
+	if err := syscall.Fstat(1, &after); err != nil {
+		t.Fatal(err)
+	}
+	if before.Ino != after.Ino || before.Dev != after.Dev {
+		t.Errorf("fd 1 was not restored: before %d/%d, after %d/%d", before.Dev, before.Ino, after.Dev, after.Ino)
+	}
+}
+
+func TestCaptureDupFDNested(t *testing.T) {
+	s := capture.New(capture.WithMethod(capture.DupFD), capture.WithStreams(capture.StreamStdout)).Start()
+	inner := capture.New(capture.WithMethod(capture.DupFD), capture.WithPassthrough(), capture.WithParent(s)).Stdout(func() {
+		fmt.Println("Hello, os.Stdout!")
+		_, _ = syscall.Write(1, []byte("Hello, fd 1!\n"))
+	})
+	_, _ = syscall.Write(1, []byte("outer\n"))
+	outer := s.Stop()
+
+	expected := "Hello, os.Stdout!\nHello, fd 1!\n"
+	if inner.Value != expected {
+		t.Errorf("Expected inner output to be %q, but got %q", expected, inner.Value)
+	}
+	if outer.Value != expected+"outer\n" {
+		t.Errorf("Expected outer output to be %q, but got %q", expected+"outer\n", outer.Value)
+	}
+}
+
+func TestCaptureDupFDOutOfOrder(t *testing.T) {
+	var before, after syscall.Stat_t
+	if err := syscall.Fstat(1, &before); err != nil {
+		t.Fatal(err)
+	}
+
+	c := capture.New(capture.WithMethod(capture.DupFD), capture.WithStreams(capture.StreamStdout))
+	first := c.Start()
+	_, _ = syscall.Write(1, []byte("first\n"))
+	second := c.With(capture.WithParent(first)).Start()
+	_, _ = syscall.Write(1, []byte("second\n"))
+
+	// Stopping the first session leaves fd 1 with the second one.
+	outFirst := first.Stop()
+	_, _ = syscall.Write(1, []byte("still second\n"))
+	outSecond := second.Stop()
+
+	if outFirst.Value != "first\n" || outSecond.Value != "second\nstill second\n" {
+		t.Errorf("Got first output %q and second output %q", outFirst.Value, outSecond.Value)
+	}
+
+	if err := syscall.Fstat(1, &after); err != nil {
+		t.Fatal(err)
+	}
+	if before.Ino != after.Ino || before.Dev != after.Dev {
+		t.Errorf("fd 1 was not restored: before %d/%d, after %d/%d", before.Dev, before.Ino, after.Dev, after.Ino)
+	}
+}
+
+func TestCaptureDupFDPanicStack(t *testing.T) {
+	output := capture.Exit(t, func() {
+		c := capture.New(capture.WithMethod(capture.DupFD), capture.WithTimeout(time.Minute))
+		_, _ = c.Run(func() {
+			panic("boom")
+		})
+	})
+
+	// The runtime reports the panic on fd 2, which must be restored first.
+	if !strings.Contains(output.Stderr, "panic: boom") || !strings.Contains(output.Stderr, "TestCaptureDupFDPanicStack") {
+		t.Errorf("Expected stderr to contain the panic with its stack, but got %q", output.Stderr)
+	}
+}
+
+func TestCaptureDupFDStdin(t *testing.T) {
+	output := capture.New(capture.WithMethod(capture.DupFD), capture.WithInput("fd 0")).Stdout(func() {
+		buf := make([]byte, 16)
+		n, _ := syscall.Read(0, buf)
+		fmt.Print(string(buf[:n]))
+	})
+
+	if output.Value != "fd 0" {
+		t.Errorf("Expected output to be %q, but got %q", "fd 0", output.Value)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+package capture
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// dupFD is not supported outside Linux.
+func dupFD(fd int) (*os.File, error) {
+	return nil, errUnsupported()
+}
+
+// setFD is not supported outside Linux.
+func setFD(fd int, f *os.File) error {
+	return errUnsupported()
+}
+
+func errUnsupported() error {
+	return fmt.Errorf("DupFD is not supported on %s: %w", runtime.GOOS, errors.ErrUnsupported)
+}
@@ -0,0 +1,216 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// DefaultExpectTimeout is how long Interaction.Expect waits for output by default.
+const DefaultExpectTimeout = 5 * time.Second
+
+// Interaction drives a captured function that reads os.Stdin, in the style of
+// expect: wait for a prompt with Expect, answer it with Send, and collect the
+// Result with Wait once the conversation is over. Expect matches the output
+// following the previous match.
+//
+// The function runs in the background and the streams stay redirected until it
+// returns, which Wait waits for: until then, output printed by the goroutine
+// driving the Interaction, or by any other code, lands in the Interaction's output
+// too. Other captures wait until then as well, so the driving goroutine must not
+// start one before calling Wait.
+type Interaction struct {
+	stdin   *io.PipeWriter
+	timeout time.Duration
+
+	mu      sync.Mutex
+	output  []byte
+	cursor  int
+	changed chan struct{}
+	done    bool
+	result  Result
+	err     error
+}
+
+// Interact starts f in the background with os.Stdin fed by Send and its output
+// captured as configured on c, stdout and stderr by default. A panic in f is
+// recovered and reported in the Result returned by Wait.
+func (c *Capture) Interact(f func()) *Interaction {
+	stdinR, stdinW := io.Pipe()
+	in := &Interaction{
+		stdin:   stdinW,
+		timeout: DefaultExpectTimeout,
+		changed: make(chan struct{}),
+	}
+
+	capture := c.With(WithStdin(stdinR), WithRecover(), WithTee(interactionWriter{in})).readingWhileRunning()
+
+	go func() {
+		result, err := capture.capture(context.Background(), f, capture.mode())
+		stdinR.CloseWithError(ErrFinished)
+		in.finish(result, err)
+	}()
+
+	return in
+}
+
+// Interact starts f in the background with os.Stdin fed by Send and stdout and
+// stderr captured.
+func Interact(f func()) *Interaction {
+	return defaultCapture.Interact(f)
+}
+
+// SetTimeout sets how long Expect and ExpectRegexp wait for matching output.
+func (in *Interaction) SetTimeout(d time.Duration) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.timeout = d
+}
+
+// Send writes s to the standard input of the function.
+func (in *Interaction) Send(s string) error {
+	_, err := io.WriteString(in.stdin, s)
+	return err
+}
+
+// SendLine writes s followed by a newline to the standard input of the function.
+func (in *Interaction) SendLine(s string) error {
+	return in.Send(s + "\n")
+}
+
+// CloseInput closes the standard input of the function, which then reads EOF.
+func (in *Interaction) CloseInput() error {
+	return in.stdin.Close()
+}
+
+// Expect waits until s appears in the output. It returns an error wrapping
+// context.DeadlineExceeded when the timeout expires first, or ErrFinished when
+// the function returns without printing s.
+func (in *Interaction) Expect(s string) error {
+	_, err := in.expect(fmt.Sprintf("%q", s), func(output []byte) (int, []string) {
+		i := bytes.Index(output, []byte(s))
+		if i < 0 {
+			return -1, nil
+		}
+		return i + len(s), []string{s}
+	})
+	return err
+}
+
+// ExpectRegexp waits until re matches the output, returning the match and its
+// submatches like regexp.FindStringSubmatch.
+func (in *Interaction) ExpectRegexp(re *regexp.Regexp) ([]string, error) {
+	return in.expect("/"+re.String()+"/", func(output []byte) (int, []string) {
+		loc := re.FindSubmatchIndex(output)
+		if loc == nil {
+			return -1, nil
+		}
+
+		match := make([]string, len(loc)/2)
+		for i := range match {
+			if loc[2*i] >= 0 {
+				match[i] = string(output[loc[2*i]:loc[2*i+1]])
+			}
+		}
+		return loc[1], match
+	})
+}
+
+// Output returns all the output captured so far.
+func (in *Interaction) Output() string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	return string(in.output)
+}
+
+// Wait closes the standard input of the function, waits for it to return and
+// returns the captured Result.
+func (in *Interaction) Wait() (Result, error) {
+	_ = in.stdin.Close()
+
+	for {
+		in.mu.Lock()
+		if in.done {
+			defer in.mu.Unlock()
+			return in.result, in.err
+		}
+		changed := in.changed
+		in.mu.Unlock()
+
+		<-changed
+	}
+}
+
+// expect waits until match finds what in the output after the previous match.
+// match returns the end of the match, or -1 if there is none.
+func (in *Interaction) expect(what string, match func([]byte) (int, []string)) ([]string, error) {
+	in.mu.Lock()
+	timer := time.NewTimer(in.timeout)
+	in.mu.Unlock()
+	defer timer.Stop()
+
+	for {
+		in.mu.Lock()
+		pending := in.output[in.cursor:]
+		if end, sub := match(pending); end >= 0 {
+			in.cursor += end
+			in.mu.Unlock()
+			return sub, nil
+		}
+		if in.done {
+			in.mu.Unlock()
+			return nil, fmt.Errorf("capture: expecting %s: %w; output: %q", what, ErrFinished, pending)
+		}
+		changed := in.changed
+		in.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return nil, fmt.Errorf("capture: expecting %s: %w; output: %q", what, context.DeadlineExceeded, in.pending())
+		}
+	}
+}
+
+func (in *Interaction) pending() []byte {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	return bytes.Clone(in.output[in.cursor:])
+}
+
+// notify wakes up everything waiting for a change. in.mu must be held.
+func (in *Interaction) notify() {
+	close(in.changed)
+	in.changed = make(chan struct{})
+}
+
+func (in *Interaction) finish(result Result, err error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.done = true
+	in.result = result
+	in.err = err
+	in.notify()
+}
+
+// interactionWriter receives the output of the function while it runs.
+type interactionWriter struct {
+	in *Interaction
+}
+
+func (w interactionWriter) Write(p []byte) (int, error) {
+	w.in.mu.Lock()
+	defer w.in.mu.Unlock()
+
+	w.in.output = append(w.in.output, p...)
+	w.in.notify()
+	return len(p), nil
+}
@@ -0,0 +1,144 @@
+package capture_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hireza/go-capture"
+)
+
+func wizard() {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Print("Name: ")
+	name, _ := reader.ReadString('\n')
+
+	fmt.Print("Age: ")
+	var age int
+	if _, err := fmt.Fscanln(reader, &age); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid age:", err)
+		return
+	}
+
+	fmt.Printf("%s is %d years old\n", strings.TrimSpace(name), age)
+}
+
+func TestInteract(t *testing.T) {
+	in := capture.Interact(wizard)
+
+	if err := in.Expect("Name: "); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.SendLine("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.Expect("Age: "); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.SendLine("42"); err != nil {
+		t.Fatal(err)
+	}
+
+	match, err := in.ExpectRegexp(regexp.MustCompile(`(\w+) is (\d+) years old`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match[1] != "bob" || match[2] != "42" {
+		t.Errorf("Expected submatches bob and 42, but got %q", match)
+	}
+
+	output, err := in.Wait()
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	expected := "Name: Age: bob is 42 years old\n"
+	if output.Value != expected {
+		t.Errorf("Expected output to be %q, but got %q", expected, output.Value)
+	}
+}
+
+func TestInteractSeparateStreams(t *testing.T) {
+	in := capture.New(capture.WithSeparateStreams()).Interact(wizard)
+
+	if err := in.Expect("Name: "); err != nil {
+		t.Fatal(err)
+	}
+	_ = in.SendLine("bob")
+	if err := in.Expect("Age: "); err != nil {
+		t.Fatal(err)
+	}
+	_ = in.SendLine("old")
+	if err := in.Expect("invalid age"); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := in.Wait()
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if output.Stdout != "Name: Age: " || !strings.HasPrefix(output.Stderr, "invalid age:") {
+		t.Errorf("Got stdout %q and stderr %q", output.Stdout, output.Stderr)
+	}
+}
+
+func TestInteractTimeout(t *testing.T) {
+	in := capture.Interact(wizard)
+	in.SetTimeout(50 * time.Millisecond)
+
+	err := in.Expect("Password: ")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expect() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if !strings.Contains(err.Error(), "Name: ") {
+		t.Errorf("Expected the error to show the pending output, but got %v", err)
+	}
+
+	if _, err := in.Wait(); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+}
+
+func TestInteractFinished(t *testing.T) {
+	in := capture.Interact(func() {
+		fmt.Println("bye")
+	})
+
+	err := in.Expect("never printed")
+	if !errors.Is(err, capture.ErrFinished) {
+		t.Errorf("Expect() error = %v, want %v", err, capture.ErrFinished)
+	}
+
+	if err := in.Send("too late\n"); !errors.Is(err, capture.ErrFinished) {
+		t.Errorf("Send() error = %v, want %v", err, capture.ErrFinished)
+	}
+	if in.Output() != "bye\n" {
+		t.Errorf("Expected output to be %q, but got %q", "bye\n", in.Output())
+	}
+}
+
+func TestInteractPanic(t *testing.T) {
+	in := capture.Interact(func() {
+		fmt.Print("Name: ")
+		panic("boom")
+	})
+
+	if err := in.Expect("Name: "); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := in.Wait()
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if output.Panic != "boom" {
+		t.Errorf("Expected panic value %q, but got %v", "boom", output.Panic)
+	}
+}
@@ -0,0 +1,106 @@
+package capture
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Overflow is what happens to captured output beyond the limit set with WithMaxBytes.
+type Overflow int
+
+const (
+	// KeepHead keeps the first bytes of the output and drops the rest.
+	KeepHead Overflow = iota
+	// KeepTail keeps the last bytes of the output and drops the rest.
+	KeepTail
+	// KeepHeadTail keeps the first and the last bytes of the output, joined by a
+	// marker telling how many bytes were dropped in between.
+	KeepHeadTail
+	// FailOnOverflow keeps the first bytes of the output like KeepHead and makes
+	// the capture fail with ErrOutputTooLarge.
+	FailOnOverflow
+)
+
+// WithMaxBytes limits each of Result.Value, Result.Stdout and Result.Stderr to n
+// bytes; the output beyond that is still read but handled as set with
+// WithOverflow, keeping the head by default. Result.Truncated reports whether
+// output was dropped and Result.TotalBytes how much was written. Events are only
+// recorded while the output is within the limit. A limit of 0 means no limit.
+func WithMaxBytes(n int64) Option {
+	return func(c *Capture) {
+		c.limit.max = max(n, 0)
+	}
+}
+
+// WithOverflow sets what happens to the output beyond the limit set with WithMaxBytes.
+func WithOverflow(overflow Overflow) Option {
+	return func(c *Capture) {
+		c.limit.overflow = overflow
+	}
+}
+
+// limit bounds the output kept by a capture.
+type limit struct {
+	max      int64
+	overflow Overflow
+}
+
+// err returns ErrOutputTooLarge if the output of col overflowed a limit that
+// makes the capture fail.
+func (l limit) err(col *collector) error {
+	if l.overflow != FailOnOverflow || !col.truncated() {
+		return nil
+	}
+	return fmt.Errorf("%w: %d bytes written, limit is %d", ErrOutputTooLarge, col.combined.total, l.max)
+}
+
+// output is a buffer keeping the part of what is written to it that its limit allows.
+type output struct {
+	limit limit
+	head  bytes.Buffer
+	tail  []byte
+	total int64
+}
+
+func (o *output) write(p []byte) {
+	o.total += int64(len(p))
+	if o.limit.max == 0 {
+		o.head.Write(p)
+		return
+	}
+
+	headMax := o.limit.max
+	switch o.limit.overflow {
+	case KeepTail:
+		headMax = 0
+	case KeepHeadTail:
+		headMax = o.limit.max / 2
+	}
+
+	if room := headMax - int64(o.head.Len()); room > 0 {
+		n := min(room, int64(len(p)))
+		o.head.Write(p[:n])
+		p = p[n:]
+	}
+
+	if tailMax := o.limit.max - headMax; tailMax > 0 && len(p) > 0 {
+		o.tail = append(o.tail, p...)
+		if extra := int64(len(o.tail)) - tailMax; extra > 0 {
+			o.tail = o.tail[extra:]
+		}
+	}
+}
+
+// truncated reports whether output was dropped.
+func (o *output) truncated() bool {
+	return o.total > int64(o.head.Len()+len(o.tail))
+}
+
+func (o *output) String() string {
+	if o.limit.overflow != KeepHeadTail || !o.truncated() {
+		return o.head.String() + string(o.tail)
+	}
+
+	elided := o.total - int64(o.head.Len()+len(o.tail))
+	return fmt.Sprintf("%s\n... %d bytes elided ...\n%s", o.head.Bytes(), elided, o.tail)
+}
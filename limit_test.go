@@ -0,0 +1,72 @@
+package capture_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hireza/go-capture"
+)
+
+func TestWithMaxBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		overflow capture.Overflow
+		expected string
+	}{
+		{name: "KeepHead", overflow: capture.KeepHead, expected: "0123456789"},
+		{name: "KeepTail", overflow: capture.KeepTail, expected: "klmnopqrst"},
+		{name: "KeepHeadTail", overflow: capture.KeepHeadTail, expected: "01234\n... 20 bytes elided ...\npqrst"},
+		{name: "FailOnOverflow", overflow: capture.FailOnOverflow, expected: "0123456789"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := capture.New(capture.WithMaxBytes(10), capture.WithOverflow(test.overflow))
+			result, err := c.Run(func() {
+				fmt.Print("0123456789")
+				fmt.Print("abcdefghijklmnopqrst")
+			})
+
+			if test.overflow == capture.FailOnOverflow {
+				if !errors.Is(err, capture.ErrOutputTooLarge) {
+					t.Errorf("Expected ErrOutputTooLarge but got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("Expected no error but got %v", err)
+			}
+
+			if result.Value != test.expected {
+				t.Errorf("Expected %q but got %q", test.expected, result.Value)
+			}
+			if !result.Truncated || result.TotalBytes != 30 {
+				t.Errorf("Expected 30 truncated bytes but got %d, truncated %v", result.TotalBytes, result.Truncated)
+			}
+		})
+	}
+}
+
+func TestWithMaxBytesWithinLimit(t *testing.T) {
+	c := capture.New(capture.WithMaxBytes(10), capture.WithOverflow(capture.FailOnOverflow))
+	result, err := c.Run(func() {
+		fmt.Print("0123456789")
+	})
+
+	if err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+	if result.Value != "0123456789" || result.Truncated || result.TotalBytes != 10 {
+		t.Errorf("Expected %q untruncated but got %q, truncated %v", "0123456789", result.Value, result.Truncated)
+	}
+}
+
+func TestWithMaxBytesPerStream(t *testing.T) {
+	c := capture.New(capture.WithMaxBytes(4), capture.WithOverflow(capture.KeepTail))
+	result := c.Both(func() {
+		fmt.Print("stdout")
+	})
+
+	if result.Stdout != "dout" || result.Stderr != "" {
+		t.Errorf("Expected %q but got %q", "dout", result.Stdout)
+	}
+}
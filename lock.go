@@ -0,0 +1,204 @@
+package capture
+
+import (
+	"context"
+	"io"
+	"os"
+	"slices"
+	"sync"
+)
+
+// streamsMu guards the process-wide streams, and the loggers writing to them,
+// while captures redirect and restore them.
+var streamsMu sync.Mutex
+
+// streamsLock serializes captures: a capture waits until the active one, and the
+// captures nested inside it with WithParent, have ended.
+var streamsLock = make(chan struct{}, 1)
+
+// hold is an acquisition of streamsLock, shared by a capture and the captures
+// nested inside it. refs is guarded by streamsMu.
+type hold struct {
+	refs int
+}
+
+// lock acquires streamsLock for s, waiting until ctx is done at most. When the
+// parent s is nested inside still holds the lock, s shares its hold instead.
+func (s *Session) lock(ctx context.Context, parent *Session) error {
+	streamsMu.Lock()
+	if parent != nil && parent.holding {
+		s.hold, s.holding = parent.hold, true
+		s.hold.refs++
+		streamsMu.Unlock()
+		return nil
+	}
+	streamsMu.Unlock()
+
+	select {
+	case streamsLock <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	s.hold, s.holding = &hold{refs: 1}, true
+	return nil
+}
+
+// unlock releases the hold of s, and streamsLock with the last one.
+func (s *Session) unlock() {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+
+	s.holding = false
+	if s.hold.refs--; s.hold.refs == 0 {
+		<-streamsLock
+	}
+}
+
+// The process-wide resources captures redirect.
+var (
+	stdoutVar  = variable(&os.Stdout)
+	stderrVar  = variable(&os.Stderr)
+	stdinVar   = variable(&os.Stdin)
+	stdoutFile = descriptor(stdoutFD)
+	stderrFile = descriptor(stderrFD)
+	stdinFile  = descriptor(stdinFD)
+)
+
+// layered is a process-wide resource captures redirect, such as os.Stdout or file
+// descriptor 1. Nested captures may run on other goroutines and need not end in
+// the order they started: every redirection adds a layer, and the resource
+// follows the latest layer left, or the value saved before the first one once
+// none is left. Output goes to the capture started last. All methods must be
+// called with streamsMu held.
+type layered[T any] struct {
+	save    func() (T, error)
+	set     func(T) error
+	discard func(T) // releases a saved value no layer needs anymore, if set
+	saved   *saved[T]
+	layers  []*layer[T]
+}
+
+// saved is the value of a resource before its first layer, shared by the layers
+// added until none is left.
+type saved[T any] struct {
+	value T
+	refs  int
+}
+
+// layer is a redirection of a resource to value.
+type layer[T any] struct {
+	res     *layered[T]
+	value   T
+	saved   *saved[T]
+	under   T // the value below the layer when it was removed
+	removed bool
+	closed  bool
+}
+
+// variable returns the resource of the file variable v.
+func variable(v **os.File) *layered[*os.File] {
+	return &layered[*os.File]{
+		save: func() (*os.File, error) {
+			return *v, nil
+		},
+		set: func(f *os.File) error {
+			*v = f
+			return nil
+		},
+	}
+}
+
+// descriptor returns the resource of the file descriptor fd. Its saved value is a
+// duplicate of the original descriptor.
+func descriptor(fd int) *layered[*os.File] {
+	return &layered[*os.File]{
+		save: func() (*os.File, error) {
+			return dupFD(fd)
+		},
+		set: func(f *os.File) error {
+			return setFD(fd, f)
+		},
+		discard: func(f *os.File) {
+			f.Close()
+		},
+	}
+}
+
+// push points the resource at value in a new layer on top.
+func (r *layered[T]) push(value T) (*layer[T], error) {
+	if len(r.layers) == 0 {
+		v, err := r.save()
+		if err != nil {
+			return nil, err
+		}
+		r.saved = &saved[T]{value: v}
+	}
+
+	l := &layer[T]{res: r, value: value, saved: r.saved}
+	r.saved.refs++
+	if err := r.set(value); err != nil {
+		l.close()
+		return nil, err
+	}
+	r.layers = append(r.layers, l)
+	return l, nil
+}
+
+// below returns the value of the layer below l, or the saved value.
+func (l *layer[T]) below() T {
+	if l.removed {
+		return l.under
+	}
+	layers := l.res.layers
+	for i := 1; i < len(layers); i++ {
+		if layers[i] == l {
+			return layers[i-1].value
+		}
+	}
+	return l.saved.value
+}
+
+// remove removes the layer. If it was on top, the resource is pointed at the
+// value below. Removing it again does nothing.
+func (l *layer[T]) remove() error {
+	if l.removed {
+		return nil
+	}
+	l.under = l.below()
+	l.removed = true
+
+	r := l.res
+	i := slices.Index(r.layers, l)
+	r.layers = slices.Delete(r.layers, i, i+1)
+	if i < len(r.layers) {
+		// A later layer stays on top.
+		return nil
+	}
+	return r.set(l.under)
+}
+
+// close releases the saved value once it is not needed by any layer it was saved
+// for, as their output may still be passed through to it after their removal.
+func (l *layer[T]) close() {
+	if l.closed {
+		return
+	}
+	l.closed = true
+	if l.saved.refs--; l.saved.refs == 0 && l.res.discard != nil {
+		l.res.discard(l.saved.value)
+	}
+}
+
+// passthrough writes to the writer it returns, looked up on every write, as the
+// captures around a nested one may end before it does.
+type passthrough func() io.Writer
+
+func (p passthrough) Write(b []byte) (int, error) {
+	streamsMu.Lock()
+	w := p()
+	streamsMu.Unlock()
+	return w.Write(b)
+}
@@ -0,0 +1,277 @@
+package capture_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hireza/go-capture"
+)
+
+func TestCaptureConcurrent(t *testing.T) {
+	stdout := os.Stdout
+
+	// Explicit goroutines run concurrently even where t.Parallel does not.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				output := capture.Stdout(func() {
+					fmt.Println(i)
+					time.Sleep(time.Millisecond)
+					fmt.Println(i)
+				})
+
+				expected := fmt.Sprintf("%d\n%d\n", i, i)
+				if output.Value != expected {
+					t.Errorf("Expected output to be %q, but got %q", expected, output.Value)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if os.Stdout != stdout {
+		t.Error("Expected os.Stdout to be restored")
+	}
+}
+
+func TestCaptureParallel(t *testing.T) {
+	methods := []capture.BufferMethod{capture.PipeDirectly, capture.PipeWithGoroutine}
+
+	for _, method := range methods {
+		for i := 0; i < 4; i++ {
+			t.Run(fmt.Sprintf("%d/%d", method, i), func(t *testing.T) {
+				t.Parallel()
+
+				output := capture.UseMethod(method).Both(func() {
+					fmt.Println("out", i)
+					time.Sleep(10 * time.Millisecond)
+					fmt.Fprintln(os.Stderr, "err", i)
+				})
+
+				if output.Stdout != fmt.Sprintln("out", i) || output.Stderr != fmt.Sprintln("err", i) {
+					t.Errorf("Got stdout %q and stderr %q for capture %d", output.Stdout, output.Stderr, i)
+				}
+			})
+		}
+	}
+}
+
+func TestCaptureWaits(t *testing.T) {
+	s := capture.Start(capture.WithStreams(capture.StreamStdout))
+
+	done := make(chan capture.Result)
+	go func() {
+		done <- capture.Stdout(func() {
+			fmt.Println("inner")
+		})
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Expected the capture to wait for the session")
+	case <-time.After(50 * time.Millisecond):
+	}
+	fmt.Println("outer")
+
+	outer := s.Stop()
+	inner := <-done
+	if inner.Value != "inner\n" || outer.Value != "outer\n" {
+		t.Errorf("Got inner output %q and outer output %q", inner.Value, outer.Value)
+	}
+}
+
+func TestCaptureWaitTimeout(t *testing.T) {
+	s := capture.Start(capture.WithStreams(capture.StreamStdout))
+	defer s.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	called := false
+	_, err := capture.RunContext(ctx, func() {
+		called = true
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RunContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if called {
+		t.Error("Expected f not to be called while waiting for the session")
+	}
+}
+
+func TestCaptureNested(t *testing.T) {
+	outer := capture.Start(capture.WithStreams(capture.StreamStdout | capture.StreamStderr))
+	fmt.Println("outer before")
+	inner := capture.New(capture.WithParent(outer)).Stdout(func() {
+		fmt.Println("inner")
+	})
+	fmt.Fprintln(os.Stderr, "outer after")
+	result := outer.Stop()
+
+	if inner.Value != "inner\n" {
+		t.Errorf("Expected inner output to be %q, but got %q", "inner\n", inner.Value)
+	}
+	if result.Value != "outer before\nouter after\n" {
+		t.Errorf("Expected outer output to be %q, but got %q", "outer before\nouter after\n", result.Value)
+	}
+}
+
+func TestCaptureNestedPassthrough(t *testing.T) {
+	methods := []capture.BufferMethod{capture.PipeDirectly, capture.PipeWithGoroutine}
+
+	for _, method := range methods {
+		t.Run(fmt.Sprint(method), func(t *testing.T) {
+			outer := capture.Start(capture.WithSeparateStreams())
+			fmt.Println("outer")
+			c := capture.New(capture.WithMethod(method), capture.WithPassthrough(), capture.WithParent(outer))
+			inner := c.Both(func() {
+				fmt.Println("inner out")
+				fmt.Fprintln(os.Stderr, "inner err")
+			})
+			result := outer.Stop()
+
+			if inner.Stdout != "inner out\n" || inner.Stderr != "inner err\n" {
+				t.Errorf("Got inner stdout %q and stderr %q", inner.Stdout, inner.Stderr)
+			}
+			if result.Stdout != "outer\ninner out\n" {
+				t.Errorf("Expected outer stdout to be %q, but got %q", "outer\ninner out\n", result.Stdout)
+			}
+			if result.Stderr != "inner err\n" {
+				t.Errorf("Expected outer stderr to be %q, but got %q", "inner err\n", result.Stderr)
+			}
+		})
+	}
+}
+
+func TestCaptureNestedRunContext(t *testing.T) {
+	outer := capture.Start(capture.WithStreams(capture.StreamStdout))
+	c := capture.New(capture.WithParent(outer), capture.WithStreams(capture.StreamStdout))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// f runs on a goroutine of its own, which nests captures inside outer too.
+	var inner capture.Result
+	middle, err := c.RunContext(ctx, func() {
+		inner = c.Stdout(func() {
+			fmt.Println("inner")
+		})
+		fmt.Println("middle")
+	})
+	if err != nil {
+		t.Fatalf("RunContext() error = %v", err)
+	}
+	fmt.Println("outer")
+	result := outer.Stop()
+
+	if inner.Value != "inner\n" || middle.Value != "middle\n" || result.Value != "outer\n" {
+		t.Errorf("Got inner output %q, middle output %q and outer output %q", inner.Value, middle.Value, result.Value)
+	}
+}
+
+func TestCaptureNestedPanic(t *testing.T) {
+	stdout := os.Stdout
+
+	outer := capture.Start(capture.WithStreams(capture.StreamStdout))
+	fmt.Println("outer")
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expected panic value %q, but got %v", "boom", r)
+			}
+		}()
+		capture.New(capture.WithParent(outer)).Stdout(func() {
+			panic("boom")
+		})
+	}()
+	result := outer.Stop()
+
+	if !strings.HasPrefix(result.Value, "outer\n") {
+		t.Errorf("Expected outer output to be %q, but got %q", "outer\n", result.Value)
+	}
+	if os.Stdout != stdout {
+		t.Error("Expected os.Stdout to be restored")
+	}
+
+	// A later capture must not wait for a lock left held by the panic.
+	if output := capture.Stdout(func() { fmt.Print("ok") }); output.Value != "ok" {
+		t.Errorf("Expected output to be %q, but got %q", "ok", output.Value)
+	}
+}
+
+func TestCaptureOutOfOrder(t *testing.T) {
+	stdout := os.Stdout
+
+	first := capture.Start(capture.WithStreams(capture.StreamStdout))
+	fmt.Println("first")
+	second := capture.Start(capture.WithStreams(capture.StreamStdout), capture.WithParent(first))
+	fmt.Println("second")
+
+	// Stopping the first session leaves the streams with the second one.
+	outFirst := first.Stop()
+	fmt.Println("still second")
+	outSecond := second.Stop()
+
+	if outFirst.Value != "first\n" {
+		t.Errorf("Expected first output to be %q, but got %q", "first\n", outFirst.Value)
+	}
+	if outSecond.Value != "second\nstill second\n" {
+		t.Errorf("Expected second output to be %q, but got %q", "second\nstill second\n", outSecond.Value)
+	}
+	if os.Stdout != stdout {
+		t.Error("Expected os.Stdout to be restored")
+	}
+
+	// The lock is released with the last nested session.
+	if output := capture.Stdout(func() { fmt.Print("ok") }); output.Value != "ok" {
+		t.Errorf("Expected output to be %q, but got %q", "ok", output.Value)
+	}
+}
+
+func TestCaptureInGoroutine(t *testing.T) {
+	outer := capture.Start()
+	fmt.Println("outer")
+
+	var inner capture.Result
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		inner = capture.New(capture.WithParent(outer)).Stdout(func() {
+			fmt.Println("inner")
+		})
+	}()
+	wg.Wait()
+	result := outer.Stop()
+
+	if inner.Value != "inner\n" || result.Value != "outer\n" {
+		t.Errorf("Got inner output %q and outer output %q", inner.Value, result.Value)
+	}
+}
+
+func TestCaptureInSubtest(t *testing.T) {
+	outer := capture.Start(capture.WithStreams(capture.StreamStderr))
+
+	var inner capture.Result
+	t.Run("inner", func(t *testing.T) {
+		inner = capture.New(capture.WithParent(outer)).Stdout(func() {
+			fmt.Println("inner")
+		})
+		fmt.Fprintln(os.Stderr, "outer")
+	})
+	result := outer.Stop()
+
+	if inner.Value != "inner\n" || result.Value != "outer\n" {
+		t.Errorf("Got inner output %q and outer output %q", inner.Value, result.Value)
+	}
+}
@@ -0,0 +1,75 @@
+package capture
+
+import (
+	"io"
+	"log"
+	"log/slog"
+)
+
+// WithLog also captures the output of the log package's standard logger, which
+// keeps writing to the original os.Stderr otherwise. It is redirected into the
+// captured stderr, so it is only captured together with stderr, and restored
+// afterwards. This covers slog's default logger as long as slog.SetDefault has
+// not been called; use WithSlog otherwise.
+func WithLog() Option {
+	return func(c *Capture) {
+		c.log = true
+	}
+}
+
+// WithSlog sets slog's default logger to one using the handler newHandler
+// returns for the captured stderr, restoring the previous default afterwards.
+// Like WithLog, it only applies when stderr is captured.
+func WithSlog(newHandler func(w io.Writer) slog.Handler) Option {
+	return func(c *Capture) {
+		c.slog = newHandler
+	}
+}
+
+// loggers are the settings of the standard loggers.
+type loggers struct {
+	writer io.Writer
+	flags  int
+	prefix string
+	slog   *slog.Logger
+}
+
+// standardLoggers is the resource of the standard loggers captures redirect.
+var standardLoggers = &layered[loggers]{save: currentLoggers, set: loggers.apply}
+
+func currentLoggers() (loggers, error) {
+	return loggers{writer: log.Writer(), flags: log.Flags(), prefix: log.Prefix(), slog: slog.Default()}, nil
+}
+
+func (l loggers) apply() error {
+	// Setting a default slog logger with another handler rewires the log
+	// package, so the log settings are applied last.
+	slog.SetDefault(l.slog)
+	log.SetOutput(l.writer)
+	log.SetFlags(l.flags)
+	log.SetPrefix(l.prefix)
+	return nil
+}
+
+// redirectLoggers redirects the standard loggers the options of c select into w.
+// streamsMu must be held.
+func (r *redirection) redirectLoggers(c *Capture, w io.Writer) error {
+	if !c.log && c.slog == nil {
+		return nil
+	}
+
+	// Set up the loggers to read back their settings, including the rewiring of
+	// the log package by slog.
+	current, _ := currentLoggers()
+	if c.slog != nil {
+		slog.SetDefault(slog.New(c.slog(w)))
+	}
+	if c.log {
+		log.SetOutput(w)
+	}
+	redirected, _ := currentLoggers()
+	_ = current.apply()
+
+	_, err := push(r, standardLoggers, redirected)
+	return err
+}
@@ -0,0 +1,62 @@
+package capture_test
+
+import (
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/hireza/go-capture"
+)
+
+func TestWithLog(t *testing.T) {
+	writer, flags := log.Writer(), log.Flags()
+
+	result := capture.New(capture.WithLog()).Stderr(func() {
+		log.SetFlags(0)
+		log.Println("from log")
+		slog.Info("from slog")
+	})
+
+	if !strings.HasPrefix(result.Value, "from log\n") || !strings.Contains(result.Value, "INFO from slog") {
+		t.Errorf("Expected the log and slog output but got %q", result.Value)
+	}
+	if log.Writer() != writer || log.Flags() != flags {
+		t.Errorf("Expected the standard logger to be restored")
+	}
+}
+
+func TestWithSlog(t *testing.T) {
+	writer, logger := log.Writer(), slog.Default()
+
+	c := capture.New(capture.WithSlog(func(w io.Writer) slog.Handler {
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		}})
+	}))
+	result := c.Output(func() {
+		slog.Info("hello", "user", "bob")
+	})
+
+	expected := `{"level":"INFO","msg":"hello","user":"bob"}` + "\n"
+	if result.Value != expected {
+		t.Errorf("Expected %q but got %q", expected, result.Value)
+	}
+	if slog.Default() != logger || log.Writer() != writer {
+		t.Errorf("Expected the default loggers to be restored")
+	}
+}
+
+func TestWithLogStdoutOnly(t *testing.T) {
+	writer := log.Writer()
+
+	capture.New(capture.WithLog()).Stdout(func() {
+		if log.Writer() != writer {
+			t.Errorf("Expected the standard logger not to be redirected without stderr")
+		}
+	})
+}
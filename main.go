@@ -2,37 +2,180 @@ package capture
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"os"
-	"regexp"
+	"runtime/debug"
 	"strconv"
-	"strings"
 	"sync"
+	"testing"
+	"time"
 )
 
 // BufferMethod defines the approach used to handle captured data.
 type BufferMethod int
 
 const (
-	// PipeDirectly uses the default pipe-based capturing (may block on large output).
+	// PipeDirectly redirects the streams into a temporary file that is read once the
+	// captured function returns, so large output never blocks the writer. Captures
+	// reading while the function runs, such as Both, use pipes instead.
 	PipeDirectly BufferMethod = iota
 
 	// PipeWithGoroutine uses a goroutine to read and buffer data, avoiding blocking.
 	PipeWithGoroutine
+
+	// DupFD redirects the underlying file descriptors 1 and 2 with dup2 instead of
+	// swapping os.Stdout and os.Stderr, so output written by cgo code, child processes
+	// and the runtime is captured too. Data is read by a goroutine. Linux only.
+	DupFD
+
+	// TempFile redirects the streams into a temporary file that is kept after the
+	// capture instead of being read into the Result, for output too large to hold
+	// in memory. See Result.Open, Result.Index and Result.Load. Both streams share
+	// the file, so Stdout, Stderr and Events stay empty, and the output is not
+	// passed on to tees, line funcs or limits.
+	TempFile
+)
+
+// readsAfter reports whether the method only reads the output once the captured
+// function has returned.
+func (method BufferMethod) readsAfter() bool {
+	return method == PipeDirectly || method == TempFile
+}
+
+// readingWhileRunning returns c, or a copy of it reading with PipeWithGoroutine
+// if its method only reads once the function has returned, for captures whose
+// output is needed while the function runs.
+func (c *Capture) readingWhileRunning() *Capture {
+	if !c.method.readsAfter() {
+		return c
+	}
+	return c.With(WithMethod(PipeWithGoroutine))
+}
+
+// File descriptors redirected by DupFD.
+const (
+	stdoutFD = 1
+	stderrFD = 2
+)
+
+// Stream identifies the output streams a capture reads from.
+type Stream int
+
+const (
+	// StreamStdout is os.Stdout, file descriptor 1.
+	StreamStdout Stream = 1 << iota
+
+	// StreamStderr is os.Stderr, file descriptor 2.
+	StreamStderr
 )
 
+// String returns the name of the stream.
+func (s Stream) String() string {
+	switch s {
+	case StreamStdout:
+		return "stdout"
+	case StreamStderr:
+		return "stderr"
+	case StreamStdout | StreamStderr:
+		return "stdout+stderr"
+	default:
+		return "Stream(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// Event is a single chunk of output as it was read from a stream. Events are in the
+// order of the reads, which across stdout and stderr is not necessarily the order
+// of the writes, as each stream is read from a pipe of its own.
+type Event struct {
+	// Stream is the stream the data was written to.
+	Stream Stream
+
+	// Bytes holds the data read.
+	Bytes []byte
+
+	// Offset is the position of Bytes within the output of its stream.
+	Offset int64
+
+	// ReadTime is when the data was read from the stream, not when it was written.
+	ReadTime time.Time
+}
+
 // Result holds the Result Result.
 type Result struct {
+	// Value holds everything captured, stdout and stderr interleaved. Writes keep
+	// their order when both streams share a pipe, as with Output; see Both otherwise.
 	Value string
+
+	// Stdout and Stderr hold the output of each stream on its own. They are
+	// populated when a stream is captured by itself or by Both.
+	Stdout string
+	Stderr string
+
+	// ExitCode is the exit code of the process the output was captured from, for
+	// captures that run a subprocess such as Exit.
+	ExitCode int
+
+	// Duration is how long the captured function or command ran.
+	Duration time.Duration
+
+	// Panic holds the value f panicked with and Stack the stack trace of the
+	// panicking goroutine, when the panic was recovered by RecoverPanic.
+	Panic any
+	Stack []byte
+
+	// Truncated reports whether output was dropped because it exceeded the limit
+	// set with WithMaxBytes, and TotalBytes how many bytes were written in total.
+	Truncated  bool
+	TotalBytes int64
+
+	events    []Event
+	file      *resultFile
+	normalize Normalizer
+}
+
+// Repanic panics again with the recovered panic value, if there is one.
+func (o Result) Repanic() {
+	if o.Panic != nil {
+		panic(o.Panic)
+	}
+}
+
+// Events returns the chunks of output in the order they were read, each tagged
+// with its stream. Within a stream this is the order of the writes; across streams
+// it is not, see Both. Events are only recorded by Record.
+func (o Result) Events() []Event {
+	return o.events
 }
 
 // Capture is used to configure and manage the capturing of output streams like os.Stdout and os.Stderr.
+// A Capture is an immutable configuration: capturing never modifies it and the methods
+// configuring it return a modified copy, so one Capture can be stored, reused and
+// shared between tests and goroutines.
 type Capture struct {
-	captureStdout bool
-	captureStderr bool
-	method        BufferMethod
-}
+	method       BufferMethod
+	streams      Stream
+	separate     bool
+	events       bool
+	timeout      time.Duration
+	recoverPanic bool
+	passthrough  bool
+	stdin        func() io.Reader
+	tees         []io.Writer
+	limit        limit
+	log          bool
+	slog         func(io.Writer) slog.Handler
+	normalize    Normalizer
+	onLine       func(Line)
+	cleanup      testing.TB
+	parent       *Session
+}
+
+// defaultCapture is the configuration used by the package-level functions.
+var defaultCapture = &Capture{}
 
 // UseMethod initializes a new Capture instance with the specified BufferMethod.
 func UseMethod(method BufferMethod) *Capture {
@@ -40,395 +183,807 @@ func UseMethod(method BufferMethod) *Capture {
 	return capture
 }
 
+// RecoverPanic returns a copy of c that recovers a panic in the captured function.
+// The streams are restored, the output written before the panic is kept and the
+// panic value and stack are reported in Result.Panic and Result.Stack. Without it,
+// the panic is not recovered: the capture is cleaned up while it propagates, and
+// the runtime reports it with the stack it was raised with.
+func (c *Capture) RecoverPanic() *Capture {
+	return c.With(WithRecover())
+}
+
+// Passthrough returns a copy of c that also passes the captured output on to where
+// the streams wrote before the capture started: the enclosing capture when
+// captures are nested with WithParent, the terminal otherwise. Output of stdout and stderr
+// sharing a pipe, as with Output, is passed on to stdout.
+func (c *Capture) Passthrough() *Capture {
+	return c.With(WithPassthrough())
+}
+
 // Stdout captures stdout.
 func (c *Capture) Stdout(f func()) Result {
-	c.captureStdout = true
-	return Result{Value: c.capture(f)}
+	return must(c.StdoutE(f))
 }
 
 // Stderr captures stderr.
 func (c *Capture) Stderr(f func()) Result {
-	c.captureStderr = true
-	return Result{Value: c.capture(f)}
+	return must(c.StderrE(f))
 }
 
 // Output captures stdout and stderr.
 func (c *Capture) Output(f func()) Result {
-	c.captureStdout = true
-	c.captureStderr = true
-	return Result{Value: c.capture(f)}
+	return must(c.OutputE(f))
+}
+
+// Both captures stdout and stderr through separate pipes, so the Result holds each
+// stream on its own as well as the combined output. Both always reads concurrently,
+// whatever the BufferMethod. The combined output in Value is not ordered across
+// streams: it follows the order in which the two pipes were read, so a write to
+// stderr may come before an earlier write to stdout, even from one goroutine. Use
+// Output when the combined output must keep the order of the writes.
+func (c *Capture) Both(f func()) Result {
+	return must(c.BothE(f))
+}
+
+// Record captures stdout and stderr like Both and also records every read as an
+// Event, so the output of each stream can be replayed with its offsets. Like the
+// combined output of Both, the events are in read order across streams.
+func (c *Capture) Record(f func()) Result {
+	return must(c.RecordE(f))
+}
+
+// StdoutE captures stdout, returning an error instead of panicking when the
+// capture itself fails.
+func (c *Capture) StdoutE(f func()) (Result, error) {
+	return c.capture(context.Background(), f, mode{streams: StreamStdout})
+}
+
+// StderrE captures stderr, returning an error instead of panicking when the
+// capture itself fails.
+func (c *Capture) StderrE(f func()) (Result, error) {
+	return c.capture(context.Background(), f, mode{streams: StreamStderr})
+}
+
+// OutputE captures stdout and stderr, returning an error instead of panicking
+// when the capture itself fails.
+func (c *Capture) OutputE(f func()) (Result, error) {
+	return c.capture(context.Background(), f, mode{streams: StreamStdout | StreamStderr})
+}
+
+// BothE is like Both but returns an error instead of panicking.
+func (c *Capture) BothE(f func()) (Result, error) {
+	return c.capture(context.Background(), f, mode{streams: StreamStdout | StreamStderr, separate: true})
+}
+
+// RecordE is like Record but returns an error instead of panicking.
+func (c *Capture) RecordE(f func()) (Result, error) {
+	return c.capture(context.Background(), f, mode{streams: StreamStdout | StreamStderr, separate: true, events: true})
+}
+
+// Run captures the streams c is configured for, stdout and stderr unless set with
+// WithStreams, returning an error instead of panicking when the capture fails.
+func (c *Capture) Run(f func()) (Result, error) {
+	return c.capture(context.Background(), f, c.mode())
+}
+
+// RunContext captures like Run, running f until it returns or ctx is done. When
+// ctx is done first, the streams are restored and the output captured so far is
+// returned together with the context's error. f cannot be stopped and keeps
+// running in the background; its later output is not captured. Writes it makes
+// to os.Stdout or os.Stderr after that may race with their restore.
+//
+// Waiting for another capture to end, see WithParent, counts towards ctx.
+//
+// When ctx has a deadline or can be canceled, f runs on a goroutine of its own,
+// so it must not call t.FailNow or t.Fatal: they would only end that goroutine,
+// which is reported as ErrGoexit, not the test. Report failures with t.Error.
+func (c *Capture) RunContext(ctx context.Context, f func()) (Result, error) {
+	return c.capture(ctx, f, c.mode())
 }
 
 // Stdout captures stdout.
 func Stdout(f func()) Result {
-	capture := &Capture{captureStdout: true}
-	return Result{Value: capture.capture(f)}
+	return must(StdoutE(f))
 }
 
 // Stderr captures stderr.
 func Stderr(f func()) Result {
-	capture := &Capture{captureStderr: true}
-	return Result{Value: capture.capture(f)}
+	return must(StderrE(f))
 }
 
 // Output captures stdout and stderr.
 func Output(f func()) Result {
-	capture := &Capture{captureStdout: true, captureStderr: true}
-	return Result{Value: capture.capture(f)}
+	return must(OutputE(f))
 }
 
-func (c *Capture) capture(f func()) string {
-	r, w, err := os.Pipe()
+// Both captures stdout and stderr separately.
+func Both(f func()) Result {
+	return must(BothE(f))
+}
+
+// Record captures stdout and stderr separately and records every read as an Event.
+func Record(f func()) Result {
+	return must(RecordE(f))
+}
+
+// StdoutE captures stdout, returning an error instead of panicking.
+func StdoutE(f func()) (Result, error) {
+	return defaultCapture.StdoutE(f)
+}
+
+// StderrE captures stderr, returning an error instead of panicking.
+func StderrE(f func()) (Result, error) {
+	return defaultCapture.StderrE(f)
+}
+
+// OutputE captures stdout and stderr, returning an error instead of panicking.
+func OutputE(f func()) (Result, error) {
+	return defaultCapture.OutputE(f)
+}
+
+// BothE captures stdout and stderr separately, returning an error instead of panicking.
+func BothE(f func()) (Result, error) {
+	return defaultCapture.BothE(f)
+}
+
+// RecordE is like Record but returns an error instead of panicking.
+func RecordE(f func()) (Result, error) {
+	return defaultCapture.RecordE(f)
+}
+
+// Run captures stdout and stderr, returning an error instead of panicking.
+func Run(f func()) (Result, error) {
+	return defaultCapture.Run(f)
+}
+
+// RunContext captures stdout and stderr until f returns or ctx is done.
+func RunContext(ctx context.Context, f func()) (Result, error) {
+	return defaultCapture.RunContext(ctx, f)
+}
+
+// must panics with err if it is not nil, for the helpers that do not return errors.
+func must(result Result, err error) Result {
 	if err != nil {
 		panic(err)
 	}
-	defer r.Close()
+	return result
+}
+
+// pipe is an os.Pipe that one or more streams are redirected into, or a
+// temporary file standing in for one.
+type pipe struct {
+	streams Stream
+	r, w    *os.File
+	name    string // of the temporary file
+}
+
+// mode selects what a single capture collects and how.
+type mode struct {
+	streams  Stream // the streams to capture
+	separate bool   // one pipe per stream instead of a shared one
+	events   bool   // record an Event for every read
+}
+
+// collector gathers the data read from the pipes of a single capture.
+type collector struct {
+	mu       sync.Mutex
+	combined output
+	stdout   output
+	stderr   output
+	record   bool
+	events   []Event
+}
+
+func newCollector(l limit, record bool) *collector {
+	c := &collector{record: record}
+	c.combined.limit = l
+	c.stdout.limit = l
+	c.stderr.limit = l
+	return c
+}
+
+func (c *collector) write(s Stream, p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var offset int64
+	c.combined.write(p)
+	switch s {
+	case StreamStdout:
+		offset = c.stdout.total
+		c.stdout.write(p)
+	case StreamStderr:
+		offset = c.stderr.total
+		c.stderr.write(p)
+	}
 
-	var buf bytes.Buffer
+	if c.record && !c.combined.truncated() {
+		c.events = append(c.events, Event{
+			Stream:   s,
+			Bytes:    bytes.Clone(p),
+			Offset:   offset,
+			ReadTime: time.Now(),
+		})
+	}
+}
 
-	if c.method == PipeWithGoroutine {
-		// Use a goroutine to read data from the pipe.
-		var wg sync.WaitGroup
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			_, _ = io.Copy(&buf, r)
-		}()
+// truncated reports whether output was dropped because of the limit. The
+// caller must hold c.mu or be the only user of c.
+func (c *collector) truncated() bool {
+	return c.combined.truncated() || c.stdout.truncated() || c.stderr.truncated()
+}
 
-		c.redirectAndExecute(w, f)
-		w.Close()
-		wg.Wait() // Wait for the goroutine to finish reading.
-	} else {
-		// Use direct pipe reading (may block if buffer is full).
-		c.redirectAndExecute(w, f)
-		w.Close()
-		_, err = io.Copy(&buf, r)
+func (c *collector) result() Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Result{
+		Value:      c.combined.String(),
+		Stdout:     c.stdout.String(),
+		Stderr:     c.stderr.String(),
+		Truncated:  c.truncated(),
+		TotalBytes: c.combined.total,
+		events:     c.events,
+	}
+}
+
+// streamWriter records everything written to it as output of a stream, passing
+// it on to the forward writers. Errors of the forward writers are ignored, they
+// must not stop the capture.
+type streamWriter struct {
+	c       *collector
+	s       Stream
+	forward []io.Writer
+}
+
+func (w streamWriter) Write(p []byte) (int, error) {
+	w.c.write(w.s, p)
+	for _, forward := range w.forward {
+		_, _ = forward.Write(p)
+	}
+	return len(p), nil
+}
+
+func (c *Capture) capture(ctx context.Context, f func(), m mode) (Result, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	s, err := c.start(ctx, m)
+	if err != nil {
+		return Result{}, err
+	}
+	// Only does anything when f panics without RecoverPanic or exits the
+	// goroutine with runtime.Goexit.
+	defer s.release()
+
+	start := time.Now()
+	caught, ctxErr := callContext(ctx, f, c.recoverPanic, func() {
+		_ = s.red.restore()
+	})
+	duration := time.Since(start)
+
+	result, err := s.StopE()
+	if ctxErr != nil {
+		err = errors.Join(ctxErr, err)
+	}
+
+	result.Duration = duration
+	if caught != nil {
+		result.Panic = caught.value
+		result.Stack = caught.stack
+	}
+
+	return result, err
+}
+
+// mode returns the mode configured by the options of c.
+func (c *Capture) mode() mode {
+	streams := c.streams
+	if streams == 0 {
+		streams = StreamStdout | StreamStderr
+	}
+	return mode{streams: streams, separate: c.separate || c.events, events: c.events}
+}
+
+// selected returns the streams to capture, grouped by the pipe they share.
+func (m mode) selected() []Stream {
+	if !m.separate {
+		return []Stream{m.streams}
+	}
+
+	var selected []Stream
+	for _, s := range []Stream{StreamStdout, StreamStderr} {
+		if m.streams&s != 0 {
+			selected = append(selected, s)
+		}
+	}
+	return selected
+}
+
+// openPipes creates a pipe for each group of streams. With backed set it creates
+// temporary files instead, which only work when they are read after all writes.
+func openPipes(selected []Stream, backed bool) ([]*pipe, error) {
+	var pipes []*pipe
+	for _, s := range selected {
+		open := openPipe
+		if backed {
+			open = openBackingFile
+		}
+		p, err := open(s)
 		if err != nil {
-			panic(err)
+			closePipes(pipes)
+			return nil, fmt.Errorf("%w: %w", ErrPipeCreate, err)
 		}
+		pipes = append(pipes, p)
 	}
+	return pipes, nil
+}
 
-	return buf.String()
+func openPipe(s Stream) (*pipe, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	return &pipe{streams: s, r: r, w: w}, nil
 }
 
-func (c *Capture) redirectAndExecute(w *os.File, f func()) {
-	if c.captureStdout {
-		stdout := os.Stdout
-		os.Stdout = w
+// openBackingFile creates a temporary file, opened once for the writes and once
+// for reading them back.
+func openBackingFile(s Stream) (*pipe, error) {
+	w, err := os.CreateTemp("", "capture-*")
+	if err != nil {
+		return nil, err
+	}
+	r, err := os.Open(w.Name())
+	if err != nil {
+		w.Close()
+		os.Remove(w.Name())
+		return nil, err
+	}
+	return &pipe{streams: s, r: r, w: w, name: w.Name()}, nil
+}
+
+func closePipes(pipes []*pipe) {
+	for _, p := range pipes {
+		p.r.Close()
+		p.w.Close()
+		if p.name != "" {
+			os.Remove(p.name)
+		}
+	}
+}
+
+// writer returns where the output read from a pipe carrying the streams s goes.
+func (c *Capture) writer(col *collector, s Stream, red *redirection, lines *lineSplitter) io.Writer {
+	w := streamWriter{c: col, s: s}
+	if c.passthrough {
+		// A pipe shared by both streams is passed through to stdout.
+		if s&StreamStdout != 0 {
+			w.forward = append(w.forward, red.stdout)
+		} else {
+			w.forward = append(w.forward, red.stderr)
+		}
+	}
+	w.forward = append(w.forward, c.tees...)
+	if c.onLine != nil {
+		w.forward = append(w.forward, lines.writer(s))
+	}
+	return w
+}
+
+// panicked is a panic recovered from the captured function.
+type panicked struct {
+	value any
+	stack []byte
+}
+
+// call runs f. With recoverPanic a panic is recovered, so the capture can be
+// finished before it is reported. Otherwise it is not touched, so the runtime
+// reports it with the stack it was raised with.
+func call(f func(), recoverPanic bool) (caught *panicked) {
+	if recoverPanic {
 		defer func() {
-			os.Stdout = stdout
+			if value := recover(); value != nil {
+				caught = &panicked{value: value, stack: debug.Stack()}
+			}
 		}()
 	}
 
-	if c.captureStderr {
-		stderr := os.Stderr
-		os.Stderr = w
+	f()
+	return nil
+}
+
+// callContext runs f like call, but stops waiting for it once ctx is done. f keeps
+// running in the background in that case, as a goroutine cannot be stopped. When
+// f does not return there, restore is called before the goroutine ends, so that a
+// panic crashing the program from it is reported on the original streams, and
+// ErrGoexit is returned if it ended with runtime.Goexit.
+func callContext(ctx context.Context, f func(), recoverPanic bool, restore func()) (*panicked, error) {
+	if ctx.Done() == nil {
+		return call(f, recoverPanic), nil
+	}
+
+	type outcome struct {
+		caught *panicked
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		var out outcome
+		returned := false
 		defer func() {
-			os.Stderr = stderr
+			if !returned {
+				restore()
+				out.err = ErrGoexit
+			}
+			done <- out
 		}()
+		out.caught = call(f, recoverPanic)
+		returned = true
+	}()
+
+	select {
+	case out := <-done:
+		return out.caught, out.err
+	case <-ctx.Done():
+		select {
+		case out := <-done:
+			return out.caught, out.err
+		default:
+			return nil, ctx.Err()
+		}
 	}
+}
 
-	f()
+// targets returns the write ends stdout and stderr are redirected into, nil for
+// a stream that is not captured.
+func targets(pipes []*pipe) (stdout, stderr *os.File) {
+	for _, p := range pipes {
+		if p.streams&StreamStdout != 0 {
+			stdout = p.w
+		}
+		if p.streams&StreamStderr != 0 {
+			stderr = p.w
+		}
+	}
+	return stdout, stderr
+}
+
+// The files package os opened for the process-wide streams.
+var (
+	processStdout = os.Stdout
+	processStderr = os.Stderr
+)
+
+// redirection is an active redirection of the process-wide streams, a layer on
+// each resource it redirects.
+type redirection struct {
+	// stdout and stderr write where the streams wrote before the redirection.
+	stdout, stderr io.Writer
+
+	layers []interface {
+		remove() error
+		close()
+	}
+	files []*os.File
+}
+
+// redirect points os.Stdout and os.Stderr, and with DupFD file descriptors 1 and 2,
+// at the given files. A nil file leaves its stream alone. The standard input and
+// the loggers are redirected as c is configured.
+func (c *Capture) redirect(stdoutW, stderrW *os.File) (_ *redirection, err error) {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+
+	r := &redirection{}
+	defer func() {
+		if err != nil {
+			_ = r.undo()
+			r.release()
+		}
+	}()
+
+	if stdoutW != nil {
+		if r.stdout, err = r.redirectStream(c.method, stdoutVar, stdoutFile, stdoutW, processStdout); err != nil {
+			return nil, err
+		}
+	}
+
+	if stderrW != nil {
+		if r.stderr, err = r.redirectStream(c.method, stderrVar, stderrFile, stderrW, processStderr); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.stdin != nil {
+		if err := r.redirectStdin(c.method, c.stdin()); err != nil {
+			return nil, err
+		}
+	}
+
+	if stderrW != nil {
+		if err := r.redirectLoggers(c, stderrW); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// redirectStream points the variable v of a stream, and with DupFD its file
+// descriptor fd, at w. It returns a writer to where the stream writes without the
+// redirection, which is the descriptor rather than process when that is the
+// file the variable is restored to.
+func (r *redirection) redirectStream(method BufferMethod, v, fd *layered[*os.File], w, process *os.File) (io.Writer, error) {
+	var fdLayer *layer[*os.File]
+	if method == DupFD {
+		l, err := push(r, fd, w)
+		if err != nil {
+			return nil, err
+		}
+		fdLayer = l
+	}
+
+	vLayer, err := push(r, v, w)
+	if err != nil {
+		return nil, err
+	}
+
+	return passthrough(func() io.Writer {
+		if below := vLayer.below(); fdLayer == nil || below != process {
+			return below
+		}
+		return fdLayer.below()
+	}), nil
+}
+
+// push points res at value in a new layer of the redirection. streamsMu must be
+// held.
+func push[T any](r *redirection, res *layered[T], value T) (*layer[T], error) {
+	l, err := res.push(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRedirect, err)
+	}
+	r.layers = append(r.layers, l)
+	return l, nil
+}
+
+// restore undoes the redirection. Calling it again does nothing.
+func (r *redirection) restore() error {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	return r.undo()
+}
+
+// undo removes the layers of the redirection, latest first. streamsMu must be held.
+func (r *redirection) undo() error {
+	var err error
+	for i := len(r.layers) - 1; i >= 0; i-- {
+		if removeErr := r.layers[i].remove(); removeErr != nil {
+			err = errors.Join(err, fmt.Errorf("%w: %w", ErrRestore, removeErr))
+		}
+	}
+	return err
+}
+
+// close releases what the redirection keeps open, once nothing is passed
+// through anymore.
+func (r *redirection) close() {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	r.release()
+}
+
+// release is close with streamsMu held.
+func (r *redirection) release() {
+	for _, l := range r.layers {
+		l.close()
+	}
+	for _, f := range r.files {
+		f.Close()
+	}
+	r.files = nil
 }
 
 // AsBool converts the Result Result to a bool.
 func (o Result) AsBool() (bool, error) {
-	result, err := strconv.ParseBool(o.Value)
-	return result, err
+	return As[bool](o)
 }
 
-// AsString converts the Result Result to a string.
+// AsString returns the captured output unchanged, without normalizing it.
 func (o Result) AsString() string {
 	return o.Value
 }
 
 // AsInt converts the Result Result to an int.
 func (o Result) AsInt() (int, error) {
-	result, err := strconv.Atoi(o.Value)
-	return result, err
+	return As[int](o)
 }
 
 // AsInt8 converts the Result Result to an int8.
 func (o Result) AsInt8() (int8, error) {
-	result, err := strconv.ParseInt(o.Value, 10, 8)
-	return int8(result), err
+	return As[int8](o)
 }
 
 // AsInt16 converts the Result Result to an int16.
 func (o Result) AsInt16() (int16, error) {
-	result, err := strconv.ParseInt(o.Value, 10, 16)
-	return int16(result), err
+	return As[int16](o)
 }
 
 // AsInt32 converts the Result Result to an int32.
 func (o Result) AsInt32() (int32, error) {
-	result, err := strconv.ParseInt(o.Value, 10, 32)
-	return int32(result), err
+	return As[int32](o)
 }
 
 // AsInt64 converts the Result Result to an int64.
 func (o Result) AsInt64() (int64, error) {
-	result, err := strconv.ParseInt(o.Value, 10, 64)
-	return result, err
+	return As[int64](o)
 }
 
 // AsUint converts the Result Result to an uint.
 func (o Result) AsUint() (uint, error) {
-	result, err := strconv.ParseUint(o.Value, 10, 64)
-	return uint(result), err
+	return As[uint](o)
 }
 
 // AsUint8 converts the Result Result to an uint8.
 func (o Result) AsUint8() (uint8, error) {
-	result, err := strconv.ParseUint(o.Value, 10, 8)
-	return uint8(result), err
+	return As[uint8](o)
 }
 
 // AsUint16 converts the Result Result to an uint16.
 func (o Result) AsUint16() (uint16, error) {
-	result, err := strconv.ParseUint(o.Value, 10, 16)
-	return uint16(result), err
+	return As[uint16](o)
 }
 
 // AsUint32 converts the Result Result to an uint32.
 func (o Result) AsUint32() (uint32, error) {
-	result, err := strconv.ParseUint(o.Value, 10, 32)
-	return uint32(result), err
+	return As[uint32](o)
 }
 
 // AsUint64 converts the Result Result to an uint64.
 func (o Result) AsUint64() (uint64, error) {
-	result, err := strconv.ParseUint(o.Value, 10, 64)
-	return result, err
+	return As[uint64](o)
 }
 
 // AsUintptr converts the Result Result to an uintptr.
 func (o Result) AsUintptr() (uintptr, error) {
-	result, err := strconv.ParseUint(o.Value, 10, 64)
-	return uintptr(result), err
+	return As[uintptr](o)
 }
 
 // AsByte converts the Result Result to an byte.
 func (o Result) AsByte() (byte, error) {
-	result, err := strconv.ParseUint(o.Value, 10, 8)
-	return byte(result), err
+	return As[byte](o)
 }
 
 // AsRune converts the Result Result to an rune.
 func (o Result) AsRune() (rune, error) {
-	result, err := strconv.ParseInt(o.Value, 10, 32)
-	return rune(result), err
+	return As[rune](o)
 }
 
 // AsFloat32 converts the Result Result to a float32.
 func (o Result) AsFloat32() (float32, error) {
-	result, err := strconv.ParseFloat(o.Value, 32)
-	return float32(result), err
+	return As[float32](o)
 }
 
 // AsFloat64 converts the Result Result to a float64.
 func (o Result) AsFloat64() (float64, error) {
-	result, err := strconv.ParseFloat(o.Value, 64)
-	return result, err
+	return As[float64](o)
 }
 
 // AsComplex64 converts the Result Result to a complex64.
 func (o Result) AsComplex64() (complex64, error) {
-	result, err := strconv.ParseComplex(o.Value, 64)
-	return complex64(result), err
+	return As[complex64](o)
 }
 
 // AsComplex128 converts the Result Result to a complex128.
 func (o Result) AsComplex128() (complex128, error) {
-	result, err := strconv.ParseComplex(o.Value, 128)
-	return result, err
-}
-
-func cleanInput(input string) string {
-	// Remove square brackets and trim any surrounding whitespace.
-	cleaned := strings.TrimSpace(input)
-	cleaned = strings.Trim(cleaned, "[]")
-
-	// Handle space-separated input by replacing spaces with commas.
-	if strings.Contains(cleaned, " ") && !strings.Contains(cleaned, ",") {
-		cleaned = strings.ReplaceAll(cleaned, " ", ",")
-	}
-
-	// Use a regex to clean up multiple commas or trailing commas.
-	re := regexp.MustCompile(`,\s*,|,\s*$`)
-	cleaned = re.ReplaceAllString(cleaned, ",")
-
-	// Ensure the result is enclosed in square brackets.
-	return "[" + cleaned + "]"
+	return As[complex128](o)
 }
 
 // AsSliceInt converts the Result Result to a slice of integers.
 func (o Result) AsSliceInt() ([]int, error) {
-	value := cleanInput(o.Value)
-
-	var result []int
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]int](o)
 }
 
 // AsSliceInt8 converts the Result Result to a slice of int8.
 func (o Result) AsSliceInt8() ([]int8, error) {
-	value := cleanInput(o.Value)
-
-	var result []int8
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]int8](o)
 }
 
 // AsSliceInt16 converts the Result Result to a slice of int16.
 func (o Result) AsSliceInt16() ([]int16, error) {
-	value := cleanInput(o.Value)
-
-	var result []int16
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]int16](o)
 }
 
 // AsSliceInt32 converts the Result Result to a slice of int32.
 func (o Result) AsSliceInt32() ([]int32, error) {
-	value := cleanInput(o.Value)
-
-	var result []int32
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]int32](o)
 }
 
 // AsSliceInt64 converts the Result Result to a slice of int64.
 func (o Result) AsSliceInt64() ([]int64, error) {
-	value := cleanInput(o.Value)
-
-	var result []int64
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]int64](o)
 }
 
 // AsSliceUint converts the Result Result to a slice of uint.
 func (o Result) AsSliceUint() ([]uint, error) {
-	value := cleanInput(o.Value)
-
-	var result []uint
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]uint](o)
 }
 
 // AsSliceUint8 converts the Result Result to a slice of uint8.
 func (o Result) AsSliceUint8() ([]uint8, error) {
-	value := cleanInput(o.Value)
-
-	var result []uint8
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]uint8](o)
 }
 
 // AsSliceUint16 converts the Result Result to a slice of uint16.
 func (o Result) AsSliceUint16() ([]uint16, error) {
-	value := cleanInput(o.Value)
-
-	var result []uint16
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]uint16](o)
 }
 
 // AsSliceUint32 converts the Result Result to a slice of uint32.
 func (o Result) AsSliceUint32() ([]uint32, error) {
-	value := cleanInput(o.Value)
-
-	var result []uint32
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]uint32](o)
 }
 
 // AsSliceUint64 converts the Result Result to a slice of uint64.
 func (o Result) AsSliceUint64() ([]uint64, error) {
-	value := cleanInput(o.Value)
-
-	var result []uint64
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]uint64](o)
 }
 
 // AsSliceUintptr converts the Result Result to a slice of uintptr.
 func (o Result) AsSliceUintptr() ([]uintptr, error) {
-	value := cleanInput(o.Value)
-
-	var result []uintptr
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]uintptr](o)
 }
 
 // AsSliceByte converts the Result Result to a slice of byte.
 func (o Result) AsSliceByte() ([]byte, error) {
-	value := cleanInput(o.Value)
-
-	var result []byte
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]byte](o)
 }
 
 // AsSliceRune converts the Result Result to a slice of rune.
 func (o Result) AsSliceRune() ([]rune, error) {
-	value := cleanInput(o.Value)
-
-	var result []rune
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]rune](o)
 }
 
 // AsSliceFloat32 converts the Result Result to a slice of float32.
 func (o Result) AsSliceFloat32() ([]float32, error) {
-	value := cleanInput(o.Value)
-
-	var result []float32
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]float32](o)
 }
 
 // AsSliceFloat64 converts the Result Result to a slice of float64.
 func (o Result) AsSliceFloat64() ([]float64, error) {
-	value := cleanInput(o.Value)
-
-	var result []float64
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]float64](o)
 }
 
 // AsSliceComplex64 converts the Result Result to a slice of complex64.
+// It reads the parenthesized numbers fmt prints, [(1+2i) (3+4i)].
 func (o Result) AsSliceComplex64() ([]complex64, error) {
-	value := cleanInput(o.Value)
-
-	var result []complex64
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]complex64](o)
 }
 
 // AsSliceComplex128 converts the Result Result to a slice of complex128.
+// It reads the parenthesized numbers fmt prints, [(1+2i) (3+4i)].
 func (o Result) AsSliceComplex128() ([]complex128, error) {
-	value := cleanInput(o.Value)
-
-	var result []complex128
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]complex128](o)
 }
 
 // AsSliceBool converts the Result Result to a slice of bools.
 func (o Result) AsSliceBool() ([]bool, error) {
-	value := cleanInput(o.Value)
-
-	var result []bool
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]bool](o)
 }
 
-// AsSliceString converts the Result Result to a slice of strings.
+// AsSliceString converts the Result Result to a slice of strings. It reads the
+// items quoted, as JSON and %q print them, or unquoted, as %v prints them,
+// [a b]. Unquoted items are separated by single spaces: output with empty items
+// or items starting or ending with a space is reported as ErrAmbiguous, and an
+// item containing a space reads as several. Use AsSliceStringSep for other
+// separators.
 func (o Result) AsSliceString() ([]string, error) {
-	value := cleanInput(o.Value)
-
-	var result []string
-	err := json.Unmarshal([]byte(value), &result)
-	return result, err
+	return As[[]string](o)
 }
@@ -1,11 +1,16 @@
 package capture_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"os"
 	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hireza/go-capture"
 )
@@ -124,6 +129,65 @@ func TestCaptureMethodOutput(t *testing.T) {
 	}
 }
 
+func TestCaptureLargeOutput(t *testing.T) {
+	methods := []capture.BufferMethod{capture.PipeDirectly, capture.PipeWithGoroutine}
+	chunk := strings.Repeat("x", 1023) + "\n"
+
+	for _, method := range methods {
+		t.Run(fmt.Sprint(method), func(t *testing.T) {
+			output := capture.UseMethod(method).Output(func() {
+				for range 8 << 10 {
+					fmt.Print(chunk)
+				}
+				fmt.Fprint(os.Stderr, "done")
+			})
+
+			if len(output.Value) != 8<<20+4 || !strings.HasSuffix(output.Value, "\ndone") {
+				t.Errorf("Expected 8MiB of output followed by %q but got %d bytes", "done", len(output.Value))
+			}
+		})
+	}
+}
+
+func TestCaptureReuse(t *testing.T) {
+	c := capture.UseMethod(capture.PipeWithGoroutine)
+
+	stdout := c.Stdout(func() {
+		fmt.Print("out")
+	})
+	s := capture.Start(capture.WithStreams(capture.StreamStdout))
+	stderr := c.With(capture.WithParent(s)).Stderr(func() {
+		fmt.Print("not captured")
+		fmt.Fprint(os.Stderr, "err")
+	})
+	outer := s.Stop()
+
+	if stdout.Value != "out" {
+		t.Errorf("Expected stdout to be %q, but got %q", "out", stdout.Value)
+	}
+	if outer.Value != "not captured" {
+		t.Errorf("Expected stdout to be left alone, but Stderr captured %q", stderr.Value)
+	}
+	if stderr.Value != "err" {
+		t.Errorf("Expected stderr to be %q, but got %q", "err", stderr.Value)
+	}
+}
+
+func TestCaptureShared(t *testing.T) {
+	c := capture.UseMethod(capture.PipeDirectly).RecoverPanic()
+
+	for i := 0; i < 4; i++ {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			output := c.Stderr(func() {
+				fmt.Fprint(os.Stderr, i)
+			})
+			if output.Value != fmt.Sprint(i) {
+				t.Errorf("Expected output to be %q, but got %q", fmt.Sprint(i), output.Value)
+			}
+		})
+	}
+}
+
 func TestCaptureStdout(t *testing.T) {
 	output := capture.Stdout(func() {
 		fmt.Println("Hello, stdout!")
@@ -158,6 +222,464 @@ func TestCaptureOutput(t *testing.T) {
 	}
 }
 
+func TestCaptureErrorVariants(t *testing.T) {
+	write := func() {
+		fmt.Print("out")
+		fmt.Fprint(os.Stderr, "err")
+	}
+
+	tests := []struct {
+		name           string
+		capture        func(func()) (capture.Result, error)
+		expectedOutput string
+	}{
+		{"StdoutE", capture.StdoutE, "out"},
+		{"StderrE", capture.StderrE, "err"},
+		{"OutputE", capture.OutputE, "outerr"},
+		{"Capture.StdoutE", capture.UseMethod(capture.PipeWithGoroutine).StdoutE, "out"},
+		{"Capture.StderrE", capture.UseMethod(capture.PipeWithGoroutine).StderrE, "err"},
+		{"Capture.OutputE", capture.UseMethod(capture.PipeWithGoroutine).OutputE, "outerr"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discardStreams(t)
+			output, err := tt.capture(write)
+			if err != nil {
+				t.Fatalf("%s() error = %v", tt.name, err)
+			}
+			if output.Value != tt.expectedOutput {
+				t.Errorf("Expected %q but got %q", tt.expectedOutput, output.Value)
+			}
+		})
+	}
+}
+
+// discardStreams points os.Stdout and os.Stderr at the null device until the
+// test ends, swallowing the output a test does not capture.
+func discardStreams(t *testing.T) {
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = null, null
+	t.Cleanup(func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		null.Close()
+	})
+}
+
+func TestCaptureRecoverPanic(t *testing.T) {
+	methods := []capture.BufferMethod{capture.PipeDirectly, capture.PipeWithGoroutine}
+
+	for _, method := range methods {
+		t.Run(fmt.Sprint(method), func(t *testing.T) {
+			stdout, stderr := os.Stdout, os.Stderr
+
+			output := capture.UseMethod(method).RecoverPanic().Output(func() {
+				fmt.Println("before panic")
+				panic("boom")
+			})
+
+			if os.Stdout != stdout || os.Stderr != stderr {
+				t.Error("Expected os.Stdout and os.Stderr to be restored")
+			}
+			if output.Value != "before panic\n" {
+				t.Errorf("Expected output to be %q, but got %q", "before panic\n", output.Value)
+			}
+			if output.Panic != "boom" {
+				t.Errorf("Expected panic value %q, but got %v", "boom", output.Panic)
+			}
+			if !strings.Contains(string(output.Stack), "TestCaptureRecoverPanic") {
+				t.Errorf("Expected stack to mention the panicking function, but got %s", output.Stack)
+			}
+		})
+	}
+}
+
+func TestCapturePanicPropagates(t *testing.T) {
+	stdout := os.Stdout
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("Expected panic value %q, but got %v", "boom", r)
+		}
+		if os.Stdout != stdout {
+			t.Error("Expected os.Stdout to be restored")
+		}
+	}()
+
+	capture.Stdout(func() {
+		panic("boom")
+	})
+}
+
+func TestCapturePanicStack(t *testing.T) {
+	captures := map[string]func(func()){
+		"PipeDirectly": func(f func()) {
+			capture.UseMethod(capture.PipeDirectly).Stdout(f)
+		},
+		"PipeWithGoroutine": func(f func()) {
+			capture.UseMethod(capture.PipeWithGoroutine).Output(f)
+		},
+		"WithTimeout": func(f func()) {
+			_, _ = capture.New(capture.WithTimeout(time.Minute)).Run(f)
+		},
+	}
+
+	for name, capt := range captures {
+		t.Run(name, func(t *testing.T) {
+			output := capture.Exit(t, func() {
+				capt(panicBoom)
+			})
+
+			if output.ExitCode != 2 {
+				t.Errorf("Expected exit code 2, but got %d", output.ExitCode)
+			}
+			if !strings.Contains(output.Stderr, "panic: boom") || !strings.Contains(output.Stderr, "capture_test.panicBoom") {
+				t.Errorf("Expected stderr to contain the panic raised in panicBoom, but got %q", output.Stderr)
+			}
+		})
+	}
+}
+
+func panicBoom() {
+	panic("boom")
+}
+
+func TestCaptureRepanic(t *testing.T) {
+	output := capture.UseMethod(capture.PipeDirectly).RecoverPanic().Stdout(func() {
+		panic("boom")
+	})
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("Expected panic value %q, but got %v", "boom", r)
+		}
+	}()
+	output.Repanic()
+	t.Error("Expected Repanic to panic")
+}
+
+func TestCaptureRecoverPanicWithoutPanic(t *testing.T) {
+	output := capture.UseMethod(capture.PipeDirectly).RecoverPanic().Stdout(func() {
+		fmt.Print("fine")
+	})
+
+	if output.Panic != nil || output.Stack != nil {
+		t.Errorf("Expected no panic, but got %v", output.Panic)
+	}
+	output.Repanic()
+}
+
+func TestCaptureRunContext(t *testing.T) {
+	output, err := capture.RunContext(context.Background(), func() {
+		fmt.Println("Hello, stdout!")
+		fmt.Fprintln(os.Stderr, "Hello, stderr!")
+	})
+	if err != nil {
+		t.Fatalf("RunContext() error = %v", err)
+	}
+
+	expected := "Hello, stdout!\nHello, stderr!\n"
+	if output.Value != expected {
+		t.Errorf("Expected output to be %q, but got %q", expected, output.Value)
+	}
+}
+
+func TestCaptureRunContextTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	output, err := capture.RunContext(ctx, func() {
+		<-release
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RunContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if output.Value != "" {
+		t.Errorf("Expected no output, but got %q", output.Value)
+	}
+}
+
+func TestCaptureRunContextPartialOutput(t *testing.T) {
+	methods := []capture.BufferMethod{capture.PipeDirectly, capture.PipeWithGoroutine}
+
+	for _, method := range methods {
+		t.Run(fmt.Sprint(method), func(t *testing.T) {
+			stdout := os.Stdout
+			release := make(chan struct{})
+			defer close(release)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			output, err := capture.UseMethod(method).RunContext(ctx, func() {
+				fmt.Println("started")
+				cancel()
+				<-release
+			})
+
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("RunContext() error = %v, want %v", err, context.Canceled)
+			}
+			if os.Stdout != stdout {
+				t.Error("Expected os.Stdout to be restored")
+			}
+			if output.Value != "started\n" {
+				t.Errorf("Expected partial output %q, but got %q", "started\n", output.Value)
+			}
+		})
+	}
+}
+
+func TestCaptureRunContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	_, err := capture.RunContext(ctx, func() {
+		called = true
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("RunContext() error = %v, want %v", err, context.Canceled)
+	}
+	if called {
+		t.Error("Expected f not to be called with a canceled context")
+	}
+}
+
+func TestCaptureRunContextGoexit(t *testing.T) {
+	methods := []capture.BufferMethod{capture.PipeDirectly, capture.PipeWithGoroutine}
+
+	for _, method := range methods {
+		t.Run(fmt.Sprint(method), func(t *testing.T) {
+			stdout := os.Stdout
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			// As t.FailNow would, on the goroutine running f.
+			output, err := capture.UseMethod(method).RunContext(ctx, func() {
+				fmt.Println("started")
+				runtime.Goexit()
+			})
+
+			if !errors.Is(err, capture.ErrGoexit) {
+				t.Errorf("RunContext() error = %v, want %v", err, capture.ErrGoexit)
+			}
+			if os.Stdout != stdout {
+				t.Error("Expected os.Stdout to be restored")
+			}
+			if output.Value != "started\n" {
+				t.Errorf("Expected partial output %q, but got %q", "started\n", output.Value)
+			}
+		})
+	}
+}
+
+func TestCaptureBoth(t *testing.T) {
+	output := capture.Both(func() {
+		fmt.Println("Hello, stdout!")
+		fmt.Fprintln(os.Stderr, "Hello, stderr!")
+	})
+
+	if output.Stdout != "Hello, stdout!\n" {
+		t.Errorf("Expected stdout to be %q, but got %q", "Hello, stdout!\n", output.Stdout)
+	}
+	if output.Stderr != "Hello, stderr!\n" {
+		t.Errorf("Expected stderr to be %q, but got %q", "Hello, stderr!\n", output.Stderr)
+	}
+	if len(output.Value) != len(output.Stdout)+len(output.Stderr) {
+		t.Errorf("Expected combined output to hold both streams, but got %q", output.Value)
+	}
+}
+
+func TestCaptureOutputOrder(t *testing.T) {
+	output := capture.Output(func() {
+		for i := 0; i < 5; i++ {
+			fmt.Print("o")
+			fmt.Fprint(os.Stderr, "e")
+		}
+	})
+
+	// Both streams share a pipe, so the writes keep their order.
+	if output.Value != "oeoeoeoeoe" {
+		t.Errorf("Expected output to be %q, but got %q", "oeoeoeoeoe", output.Value)
+	}
+}
+
+func TestCaptureBothOrder(t *testing.T) {
+	s := capture.Start(capture.WithSeparateStreams())
+
+	// Across separate pipes the combined output follows the order of the reads, so
+	// each write is waited for until it has been read before the next one.
+	for i := 0; i < 5; i++ {
+		fmt.Print("o")
+		waitForOutput(t, s, int64(2*i+1))
+		fmt.Fprint(os.Stderr, "e")
+		waitForOutput(t, s, int64(2*i+2))
+	}
+	output := s.Stop()
+
+	if output.Value != "oeoeoeoeoe" {
+		t.Errorf("Expected output to be %q, but got %q", "oeoeoeoeoe", output.Value)
+	}
+	if output.Stdout != "ooooo" || output.Stderr != "eeeee" {
+		t.Errorf("Got stdout %q and stderr %q", output.Stdout, output.Stderr)
+	}
+}
+
+// waitForOutput waits until s has read n bytes.
+func waitForOutput(t *testing.T, s *capture.Session, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Snapshot().TotalBytes < n {
+		if time.Now().After(deadline) {
+			s.Stop()
+			t.Fatalf("Expected %d bytes to be read, but got %d", n, s.Snapshot().TotalBytes)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCaptureMethodBoth(t *testing.T) {
+	methods := []capture.BufferMethod{capture.PipeDirectly, capture.PipeWithGoroutine}
+
+	for _, method := range methods {
+		t.Run(fmt.Sprint(method), func(t *testing.T) {
+			output := capture.UseMethod(method).Both(func() {
+				fmt.Println("data")
+				fmt.Fprintln(os.Stderr, "error")
+				fmt.Println("more data")
+			})
+
+			if output.Stdout != "data\nmore data\n" {
+				t.Errorf("Expected stdout to be %q, but got %q", "data\nmore data\n", output.Stdout)
+			}
+			if output.Stderr != "error\n" {
+				t.Errorf("Expected stderr to be %q, but got %q", "error\n", output.Stderr)
+			}
+		})
+	}
+}
+
+func TestCaptureRecord(t *testing.T) {
+	output := capture.Record(func() {
+		fmt.Print("one ")
+		fmt.Fprint(os.Stderr, "oops ")
+		fmt.Print("two")
+	})
+
+	events := output.Events()
+	if len(events) == 0 {
+		t.Fatal("Expected events to be recorded")
+	}
+
+	streams := map[capture.Stream]string{}
+	for i, event := range events {
+		if event.Offset != int64(len(streams[event.Stream])) {
+			t.Errorf("Event %d: expected offset %d, but got %d", i, len(streams[event.Stream]), event.Offset)
+		}
+		if i > 0 && event.ReadTime.Before(events[i-1].ReadTime) {
+			t.Errorf("Event %d: read time %v is before the previous event", i, event.ReadTime)
+		}
+		streams[event.Stream] += string(event.Bytes)
+	}
+
+	if streams[capture.StreamStdout] != output.Stdout || output.Stdout != "one two" {
+		t.Errorf("Expected stdout events to replay %q, but got %q", output.Stdout, streams[capture.StreamStdout])
+	}
+	if streams[capture.StreamStderr] != output.Stderr || output.Stderr != "oops " {
+		t.Errorf("Expected stderr events to replay %q, but got %q", output.Stderr, streams[capture.StreamStderr])
+	}
+}
+
+func TestCaptureRecordOrder(t *testing.T) {
+	s := capture.Start(capture.WithEvents())
+
+	// Events are in read order, so each write is waited for until it has been read
+	// before the next one on the other stream.
+	writes := []struct {
+		stream capture.Stream
+		text   string
+	}{
+		{capture.StreamStdout, "one "},
+		{capture.StreamStderr, "oops "},
+		{capture.StreamStdout, "two "},
+		{capture.StreamStderr, "again"},
+	}
+	var total int64
+	for _, w := range writes {
+		if w.stream == capture.StreamStdout {
+			fmt.Print(w.text)
+		} else {
+			fmt.Fprint(os.Stderr, w.text)
+		}
+		total += int64(len(w.text))
+		waitForOutput(t, s, total)
+	}
+	output := s.Stop()
+
+	events := output.Events()
+	if len(events) != len(writes) {
+		t.Fatalf("Expected %d events, but got %d", len(writes), len(events))
+	}
+	for i, w := range writes {
+		if events[i].Stream != w.stream || string(events[i].Bytes) != w.text {
+			t.Errorf("Event %d: expected %v %q, but got %v %q", i, w.stream, w.text, events[i].Stream, events[i].Bytes)
+		}
+	}
+	if output.Value != "one oops two again" {
+		t.Errorf("Expected output to be %q, but got %q", "one oops two again", output.Value)
+	}
+}
+
+func TestCaptureEventsNotRecordedByDefault(t *testing.T) {
+	output := capture.Both(func() {
+		fmt.Println("Hello, stdout!")
+	})
+
+	if events := output.Events(); events != nil {
+		t.Errorf("Expected no events, but got %v", events)
+	}
+}
+
+func TestStreamString(t *testing.T) {
+	tests := []struct {
+		stream capture.Stream
+		want   string
+	}{
+		{capture.StreamStdout, "stdout"},
+		{capture.StreamStderr, "stderr"},
+		{capture.StreamStdout | capture.StreamStderr, "stdout+stderr"},
+		{capture.Stream(8), "Stream(8)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.stream.String(); got != tt.want {
+			t.Errorf("String() got = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestCaptureSingleStreamFields(t *testing.T) {
+	output := capture.Stdout(func() {
+		fmt.Println("Hello, stdout!")
+	})
+
+	if output.Stdout != output.Value || output.Stderr != "" {
+		t.Errorf("Expected Stdout %q and empty Stderr, but got %q and %q", output.Value, output.Stdout, output.Stderr)
+	}
+}
+
 func TestCapturedOutputAsBool(t *testing.T) {
 	tests := []struct {
 		input   string
@@ -701,6 +1223,9 @@ func TestCapturedOutputAsSliceInt(t *testing.T) {
 		{"[1, 2, 3]", []int{1, 2, 3}, false},
 		{"[\"hello\", \"world\", 1]", []int{0, 0, 1}, true},
 		{"[]", []int{}, false},
+		{"1 2 3", []int{1, 2, 3}, false},
+		{"1,2,3", []int{1, 2, 3}, false},
+		{"", []int{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -729,6 +1254,9 @@ func TestCapturedOutputAsSliceInt8(t *testing.T) {
 		{"[1, 2, 3]", []int8{1, 2, 3}, false},
 		{"[\"hello\", \"world\", 1]", []int8{0, 0, 1}, true},
 		{"[]", []int8{}, false},
+		{"1 2 3", []int8{1, 2, 3}, false},
+		{"1,2,3", []int8{1, 2, 3}, false},
+		{"", []int8{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -757,6 +1285,9 @@ func TestCapturedOutputAsSliceInt16(t *testing.T) {
 		{"[1, 2, 3]", []int16{1, 2, 3}, false},
 		{"[\"hello\", \"world\", 1]", []int16{0, 0, 1}, true},
 		{"[]", []int16{}, false},
+		{"1 2 3", []int16{1, 2, 3}, false},
+		{"1,2,3", []int16{1, 2, 3}, false},
+		{"", []int16{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -785,6 +1316,9 @@ func TestCapturedOutputAsSliceInt32(t *testing.T) {
 		{"[1, 2, 3]", []int32{1, 2, 3}, false},
 		{"[\"hello\", \"world\", 1]", []int32{0, 0, 1}, true},
 		{"[]", []int32{}, false},
+		{"1 2 3", []int32{1, 2, 3}, false},
+		{"1,2,3", []int32{1, 2, 3}, false},
+		{"", []int32{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -813,6 +1347,9 @@ func TestCapturedOutputAsSliceInt64(t *testing.T) {
 		{"[1, 2, 3]", []int64{1, 2, 3}, false},
 		{"[\"hello\", \"world\", 1]", []int64{0, 0, 1}, true},
 		{"[]", []int64{}, false},
+		{"1 2 3", []int64{1, 2, 3}, false},
+		{"1,2,3", []int64{1, 2, 3}, false},
+		{"", []int64{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -841,6 +1378,9 @@ func TestCapturedOutputAsSliceUint(t *testing.T) {
 		{"[1, 2, 3]", []uint{1, 2, 3}, false},
 		{"[\"hello\", \"world\", 1]", []uint{0, 0, 1}, true},
 		{"[]", []uint{}, false},
+		{"1 2 3", []uint{1, 2, 3}, false},
+		{"1,2,3", []uint{1, 2, 3}, false},
+		{"", []uint{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -869,6 +1409,9 @@ func TestCapturedOutputAsSliceUint8(t *testing.T) {
 		{"[1, 2, 3]", []uint8{1, 2, 3}, false},
 		{"[\"hello\", \"world\", 1]", []uint8{0, 0, 1}, true},
 		{"[]", []uint8{}, false},
+		{"1 2 3", []uint8{1, 2, 3}, false},
+		{"1,2,3", []uint8{1, 2, 3}, false},
+		{"", []uint8{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -897,6 +1440,9 @@ func TestCapturedOutputAsSliceUint16(t *testing.T) {
 		{"[1, 2, 3]", []uint16{1, 2, 3}, false},
 		{"[\"hello\", \"world\", 1]", []uint16{0, 0, 1}, true},
 		{"[]", []uint16{}, false},
+		{"1 2 3", []uint16{1, 2, 3}, false},
+		{"1,2,3", []uint16{1, 2, 3}, false},
+		{"", []uint16{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -925,6 +1471,9 @@ func TestCapturedOutputAsSliceUint32(t *testing.T) {
 		{"[1, 2, 3]", []uint32{1, 2, 3}, false},
 		{"[\"hello\", \"world\", 1]", []uint32{0, 0, 1}, true},
 		{"[]", []uint32{}, false},
+		{"1 2 3", []uint32{1, 2, 3}, false},
+		{"1,2,3", []uint32{1, 2, 3}, false},
+		{"", []uint32{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -953,6 +1502,9 @@ func TestCapturedOutputAsSliceUint64(t *testing.T) {
 		{"[1, 2, 3]", []uint64{1, 2, 3}, false},
 		{"[\"hello\", \"world\", 1]", []uint64{0, 0, 1}, true},
 		{"[]", []uint64{}, false},
+		{"1 2 3", []uint64{1, 2, 3}, false},
+		{"1,2,3", []uint64{1, 2, 3}, false},
+		{"", []uint64{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -981,6 +1533,9 @@ func TestCapturedOutputAsSliceUintptr(t *testing.T) {
 		{"[1, 2, 3]", []uintptr{1, 2, 3}, false},
 		{"[\"hello\", \"world\", 1]", []uintptr{0, 0, 1}, true},
 		{"[]", []uintptr{}, false},
+		{"1 2 3", []uintptr{1, 2, 3}, false},
+		{"1,2,3", []uintptr{1, 2, 3}, false},
+		{"", []uintptr{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -1009,6 +1564,9 @@ func TestCapturedOutputAsSliceByte(t *testing.T) {
 		{"[97, 98, 99]", []byte{'a', 'b', 'c'}, false},
 		{"[\"hello\", \"world\", 1]", []byte{0, 0, 1}, true},
 		{"[]", []byte{}, false},
+		{"97 98 99", []byte{'a', 'b', 'c'}, false},
+		{"97,98,99", []byte{'a', 'b', 'c'}, false},
+		{"", []byte{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -1037,6 +1595,9 @@ func TestCapturedOutputAsSliceRune(t *testing.T) {
 		{"[\"a\", \"b\", \"c\"]", []rune{0, 0, 0}, true},
 		{"[\"hello\", \"world\", 1]", []rune{0, 0, 1}, true},
 		{"[]", []rune{}, false},
+		{"97 98 99", []rune{'a', 'b', 'c'}, false},
+		{"97,98,99", []rune{'a', 'b', 'c'}, false},
+		{"", []rune{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -1065,6 +1626,9 @@ func TestCapturedOutputAsSliceFloat32(t *testing.T) {
 		{"[1.0, 2.5, 3.14]", []float32{1.0, 2.5, 3.14}, false},
 		{"[\"hello\", \"world\", 1]", []float32{0, 0, 1}, true},
 		{"[]", []float32{}, false},
+		{"1.0 2.5 3.14", []float32{1.0, 2.5, 3.14}, false},
+		{"1.0,2.5,3.14", []float32{1.0, 2.5, 3.14}, false},
+		{"", []float32{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -1102,6 +1666,9 @@ func TestCapturedOutputAsSliceFloat64(t *testing.T) {
 		{"[1.0, 2.5, 3.14]", []float64{1.0, 2.5, 3.14}, false},
 		{"[\"hello\", \"world\", 1]", []float64{0, 0, 1}, true},
 		{"[]", []float64{}, false},
+		{"1.0 2.5 3.14", []float64{1.0, 2.5, 3.14}, false},
+		{"1.0,2.5,3.14", []float64{1.0, 2.5, 3.14}, false},
+		{"", []float64{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -1129,7 +1696,17 @@ func TestCapturedOutputAsSliceComplex64(t *testing.T) {
 		want    []complex64
 		wantErr bool
 	}{
+		{"[(1+2i) (3+4i)]", []complex64{complex(1, 2), complex(3, 4)}, false},
+		{"[(1+2i), (-3.5-4i)]", []complex64{complex(1, 2), complex(-3.5, -4)}, false},
+		{"[(1+2i)(3+4i)]", []complex64{complex(1, 2), complex(3, 4)}, false},
+		{"[( 1 + 2i ) (0+0i)]", []complex64{complex(1, 2), 0}, false},
+		{"[(1+2i) (3+4i]", nil, true},
+		{"[(1+2i) 3+4i]", []complex64{complex(1, 2), complex(3, 4)}, false},
+		{"[(1+2i) (x)]", []complex64{complex(1, 2), 0}, true},
 		{"[]", []complex64{}, false},
+		{"(1+2i) (3+4i)", []complex64{complex(1, 2), complex(3, 4)}, false},
+		{"(1+2i),(3+4i)", []complex64{complex(1, 2), complex(3, 4)}, false},
+		{"", []complex64{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -1154,7 +1731,17 @@ func TestCapturedOutputAsSliceComplex128(t *testing.T) {
 		want    []complex128
 		wantErr bool
 	}{
+		{"[(1+2i) (3+4i)]", []complex128{complex(1, 2), complex(3, 4)}, false},
+		{"[(1+2i), (-3.5-4i)]", []complex128{complex(1, 2), complex(-3.5, -4)}, false},
+		{"[(1+2i)(3+4i)]", []complex128{complex(1, 2), complex(3, 4)}, false},
+		{"[( 1 + 2i ) (0+0i)]", []complex128{complex(1, 2), 0}, false},
+		{"[(1+2i) (3+4i]", nil, true},
+		{"[(1+2i) 3+4i]", []complex128{complex(1, 2), complex(3, 4)}, false},
+		{"[(1+2i) (x)]", []complex128{complex(1, 2), 0}, true},
 		{"[]", []complex128{}, false},
+		{"(1+2i) (3+4i)", []complex128{complex(1, 2), complex(3, 4)}, false},
+		{"(1+2i),(3+4i)", []complex128{complex(1, 2), complex(3, 4)}, false},
+		{"", []complex128{}, false},
 		{"invalid", nil, true},
 	}
 
@@ -1182,6 +1769,9 @@ func TestCapturedOutputAsSliceBool(t *testing.T) {
 		{"[true false true]", []bool{true, false, true}, false},
 		{"[true, false, true]", []bool{true, false, true}, false},
 		{"[]", []bool{}, false},
+		{"true false true", []bool{true, false, true}, false},
+		{"true,false,true", []bool{true, false, true}, false},
+		{"", []bool{}, false},
 		{"invalid", nil, true},
 		{"[true, false, true]", []bool{true, false, true}, false},
 		{"[]", []bool{}, false},
@@ -1215,7 +1805,12 @@ func TestCapturedOutputAsSliceString(t *testing.T) {
 		{"[\"hello\", \"world\"]", []string{"hello", "world"}, false},
 		{"[\"hello\", \"world\", \"2\"]", []string{"hello", "world", "2"}, false},
 		{"[]", []string{}, false},
+		{`"hello" "world"`, []string{"hello", "world"}, false},
+		{`"hello","world"`, []string{"hello", "world"}, false},
+		{"", []string{}, false},
 		{"invalid", nil, true},
+		{"a b]", nil, true},
+		{"[a b", nil, true},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,79 @@
+package capture
+
+import (
+	"time"
+)
+
+// Option configures a Capture created by New.
+type Option func(*Capture)
+
+// New returns a Capture configured by opts. Without options it captures stdout and
+// stderr through a shared pipe read once the captured function returns, like the
+// package-level functions.
+func New(opts ...Option) *Capture {
+	return defaultCapture.With(opts...)
+}
+
+// With returns a copy of c with opts applied.
+func (c *Capture) With(opts ...Option) *Capture {
+	capture := *c
+	for _, opt := range opts {
+		opt(&capture)
+	}
+	return &capture
+}
+
+// WithMethod sets the BufferMethod used to capture.
+func WithMethod(method BufferMethod) Option {
+	return func(c *Capture) {
+		c.method = method
+	}
+}
+
+// WithStreams sets the streams Run and RunContext capture, such as StreamStdout or
+// StreamStdout|StreamStderr.
+func WithStreams(streams Stream) Option {
+	return func(c *Capture) {
+		c.streams = streams & (StreamStdout | StreamStderr)
+	}
+}
+
+// WithSeparateStreams makes Run and RunContext read each stream through its own
+// pipe, as Both does, so Result.Stdout and Result.Stderr are populated.
+func WithSeparateStreams() Option {
+	return func(c *Capture) {
+		c.separate = true
+	}
+}
+
+// WithEvents makes Run and RunContext record an Event for every read, as Record does.
+func WithEvents() Option {
+	return func(c *Capture) {
+		c.events = true
+	}
+}
+
+// WithTimeout bounds how long every capture made with the Capture waits for the
+// captured function, as if it were run by RunContext with a context that expires
+// after d. The panicking helpers such as Stdout panic when the timeout expires.
+// Like with RunContext, f must not call t.FailNow or t.Fatal.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Capture) {
+		c.timeout = d
+	}
+}
+
+// WithRecover recovers a panic in the captured function, see Capture.RecoverPanic.
+func WithRecover() Option {
+	return func(c *Capture) {
+		c.recoverPanic = true
+	}
+}
+
+// WithPassthrough passes the captured output on to where the streams wrote before
+// the capture started, see Capture.Passthrough.
+func WithPassthrough() Option {
+	return func(c *Capture) {
+		c.passthrough = true
+	}
+}
@@ -0,0 +1,150 @@
+package capture_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hireza/go-capture"
+)
+
+func TestNew(t *testing.T) {
+	output, err := capture.New().Run(func() {
+		fmt.Println("Hello, stdout!")
+		fmt.Fprintln(os.Stderr, "Hello, stderr!")
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	expected := "Hello, stdout!\nHello, stderr!\n"
+	if output.Value != expected {
+		t.Errorf("Expected output to be %q, but got %q", expected, output.Value)
+	}
+}
+
+func TestWithStreams(t *testing.T) {
+	tests := []struct {
+		name           string
+		streams        capture.Stream
+		expectedOutput string
+	}{
+		{"stdout", capture.StreamStdout, "out"},
+		{"stderr", capture.StreamStderr, "err"},
+		{"both", capture.StreamStdout | capture.StreamStderr, "outerr"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := capture.New(capture.WithMethod(capture.PipeWithGoroutine), capture.WithStreams(tt.streams))
+
+			discardStreams(t)
+			output, err := c.Run(func() {
+				fmt.Print("out")
+				fmt.Fprint(os.Stderr, "err")
+			})
+
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if output.Value != tt.expectedOutput {
+				t.Errorf("Expected output to be %q, but got %q", tt.expectedOutput, output.Value)
+			}
+		})
+	}
+}
+
+func TestWithSeparateStreams(t *testing.T) {
+	output, err := capture.New(capture.WithSeparateStreams()).Run(func() {
+		fmt.Print("out")
+		fmt.Fprint(os.Stderr, "err")
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if output.Stdout != "out" || output.Stderr != "err" {
+		t.Errorf("Got stdout %q and stderr %q", output.Stdout, output.Stderr)
+	}
+	if output.Events() != nil {
+		t.Errorf("Expected no events, but got %v", output.Events())
+	}
+}
+
+func TestWithEvents(t *testing.T) {
+	output, err := capture.New(capture.WithStreams(capture.StreamStderr), capture.WithEvents()).Run(func() {
+		fmt.Fprint(os.Stderr, "err")
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	events := output.Events()
+	if len(events) != 1 || events[0].Stream != capture.StreamStderr || string(events[0].Bytes) != "err" {
+		t.Errorf("Expected a single stderr event, but got %v", events)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	c := capture.New(capture.WithTimeout(50 * time.Millisecond))
+
+	_, err := c.Run(func() {
+		<-release
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	defer func() {
+		r := recover()
+		if err, ok := r.(error); !ok || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected Stdout to panic with %v, but got %v", context.DeadlineExceeded, r)
+		}
+	}()
+	c.Stdout(func() {
+		<-release
+	})
+}
+
+func TestWithRecover(t *testing.T) {
+	output, err := capture.New(capture.WithRecover()).Run(func() {
+		panic("boom")
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Panic != "boom" {
+		t.Errorf("Expected panic value %q, but got %v", "boom", output.Panic)
+	}
+}
+
+func TestCaptureWith(t *testing.T) {
+	base := capture.New(capture.WithStreams(capture.StreamStdout))
+	derived := base.With(capture.WithStreams(capture.StreamStderr))
+
+	output, err := base.Run(func() {
+		fmt.Print("out")
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Value != "out" {
+		t.Errorf("Expected the base configuration to be unchanged, but got %q", output.Value)
+	}
+
+	output, err = derived.Run(func() {
+		fmt.Fprint(os.Stderr, "err")
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if output.Value != "err" {
+		t.Errorf("Expected output to be %q, but got %q", "err", output.Value)
+	}
+}
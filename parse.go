@@ -0,0 +1,292 @@
+package capture
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// nodeKind is the kind of a value parsed from captured output.
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota // a bare word such as 42, true or 1.5
+	quotedNode                 // a quoted string such as "a b"
+	listNode                   // [1 2 3], [1, 2, 3], {Alice 30} or []int{1, 2, 3}
+	mapNode                    // map[a:1 b:2], {Name:Alice Age:30} or {"a": 1}
+	nilNode                    // []int(nil)
+)
+
+// node is a value parsed from captured output, before it is decoded into a Go
+// value of a particular type.
+type node struct {
+	kind  nodeKind
+	text  string // of a scalar, or the unquoted string
+	keys  []node // of a map, one for every item
+	items []node // of a list or map
+}
+
+func (k nodeKind) String() string {
+	switch k {
+	case scalarNode:
+		return "value"
+	case quotedNode:
+		return "quoted string"
+	case listNode:
+		return "list"
+	case mapNode:
+		return "map"
+	default:
+		return "nil"
+	}
+}
+
+// parse parses s as a single value.
+func parse(s string) (node, error) {
+	p := &parser{s: s}
+	n, err := p.value("")
+	if err != nil {
+		return node{}, err
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return node{}, p.errorf("unexpected %q after value", p.s[p.pos:])
+	}
+	return n, nil
+}
+
+// parser parses the text of printed lists, maps and structs, both as JSON and
+// in the formats the fmt package prints them in with the verbs %v, %+v and %#v.
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("syntax error at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// value parses the value at the current position. A bare word ends at white
+// space, at one of ",[]{}" or at one of the bytes in stop.
+func (p *parser) value(stop string) (node, error) {
+	p.skipSpace()
+	if p.pos == len(p.s) {
+		return node{}, p.errorf("unexpected end of input")
+	}
+
+	// %v prints a pointer to a composite value as &{...}, %#v as &T{...}.
+	if p.s[p.pos] == '&' {
+		p.pos++
+		if p.pos < len(p.s) && p.s[p.pos] == '{' || p.literalType() {
+			return p.value(stop)
+		}
+		p.pos--
+	}
+
+	// %#v prints composite literals, []int{1, 2}, and nil values, []int(nil).
+	if p.literalType() {
+		if strings.HasPrefix(p.s[p.pos:], "(nil)") {
+			p.pos += len("(nil)")
+			return node{kind: nilNode}, nil
+		}
+		p.pos++
+		return p.braced('}')
+	}
+
+	switch rest := p.s[p.pos:]; {
+	case rest[0] == '"' || rest[0] == '`':
+		return p.quoted()
+	case rest[0] == '(':
+		return p.parenthesized()
+	case rest[0] == '[':
+		p.pos++
+		return p.list()
+	case rest[0] == '{':
+		p.pos++
+		return p.braced('}')
+	case strings.HasPrefix(rest, "map["):
+		p.pos += len("map[")
+		return p.braced(']')
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n,[]{}"+stop, p.s[p.pos]) < 0 {
+		p.pos++
+	}
+	if p.pos == start {
+		return node{}, p.errorf("unexpected %q", p.s[p.pos])
+	}
+	return node{kind: scalarNode, text: p.s[start:p.pos]}, nil
+}
+
+// literalType skips the Go type at the current position if it is followed by
+// a composite literal or "(nil)", as %#v prints them, and reports whether it did.
+func (p *parser) literalType() bool {
+	start := p.pos
+	if p.goType() && p.pos < len(p.s) && (p.s[p.pos] == '{' || strings.HasPrefix(p.s[p.pos:], "(nil)")) {
+		return true
+	}
+	p.pos = start
+	return false
+}
+
+// goType skips a Go type as %#v prints it, such as []main.Person or
+// map[string]*int, and reports whether there was one.
+func (p *parser) goType() bool {
+	switch {
+	case p.consume("*"):
+		return p.goType()
+	case p.consume("("):
+		return p.goType() && p.consume(")")
+	case p.consume("map["):
+		return p.goType() && p.consume("]") && p.goType()
+	case p.consume("["):
+		for p.pos < len(p.s) && '0' <= p.s[p.pos] && p.s[p.pos] <= '9' {
+			p.pos++
+		}
+		return p.consume("]") && p.goType()
+	case p.consume("struct {"), p.consume("interface {"):
+		// Skip the fields or methods up to the matching brace.
+		for depth := 1; depth > 0; p.pos++ {
+			if p.pos == len(p.s) {
+				return false
+			}
+			switch p.s[p.pos] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+		}
+		return true
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && isIdentByte(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// isIdentByte reports whether b may be part of a qualified identifier such as
+// main.Person.
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '.' || b >= 0x80 ||
+		'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9'
+}
+
+func (p *parser) consume(prefix string) bool {
+	if strings.HasPrefix(p.s[p.pos:], prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// parenthesized parses a parenthesized value such as the complex number (1+2i),
+// as fmt prints it, into a bare word. White space inside the parentheses is
+// dropped, so that (1 + 2i) reads the same.
+func (p *parser) parenthesized() (node, error) {
+	start := p.pos
+	for depth := 0; ; p.pos++ {
+		if p.pos == len(p.s) {
+			p.pos = start
+			return node{}, p.errorf("missing ')'")
+		}
+		switch p.s[p.pos] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		if depth == 0 {
+			p.pos++
+			break
+		}
+	}
+
+	text := strings.Join(strings.Fields(p.s[start:p.pos]), "")
+	return node{kind: scalarNode, text: text}, nil
+}
+
+func (p *parser) quoted() (node, error) {
+	quoted, err := strconv.QuotedPrefix(p.s[p.pos:])
+	if err != nil {
+		return node{}, p.errorf("unterminated string")
+	}
+	text, err := strconv.Unquote(quoted)
+	if err != nil {
+		return node{}, p.errorf("invalid string %s", quoted)
+	}
+	p.pos += len(quoted)
+	return node{kind: quotedNode, text: text}, nil
+}
+
+// list parses the items of a list up to the closing bracket. Items are
+// separated by white space, commas, or both.
+func (p *parser) list() (node, error) {
+	n := node{kind: listNode, items: []node{}}
+	for {
+		if p.skipSpace(); p.pos < len(p.s) && p.s[p.pos] == ']' {
+			p.pos++
+			return n, nil
+		}
+
+		item, err := p.value("")
+		if err != nil {
+			return node{}, err
+		}
+		n.items = append(n.items, item)
+
+		if p.skipSpace(); p.pos < len(p.s) && p.s[p.pos] == ',' {
+			p.pos++
+		}
+	}
+}
+
+// braced parses the items of a struct, map or composite literal up to the
+// closing byte end: either positional items, {Alice 30}, or key:value pairs,
+// {Name:Alice Age:30}. Items are separated by white space, commas, or both.
+func (p *parser) braced(end byte) (node, error) {
+	n := node{kind: listNode, items: []node{}}
+	for {
+		if p.skipSpace(); p.pos < len(p.s) && p.s[p.pos] == end {
+			p.pos++
+			return n, nil
+		}
+
+		item, err := p.value(":")
+		if err != nil {
+			return node{}, err
+		}
+
+		if p.skipSpace(); p.pos < len(p.s) && p.s[p.pos] == ':' {
+			if n.kind != mapNode && len(n.items) > 0 {
+				return node{}, p.errorf("unexpected ':' after positional items")
+			}
+			p.pos++
+
+			value, err := p.value("")
+			if err != nil {
+				return node{}, err
+			}
+			n.kind = mapNode
+			n.keys = append(n.keys, item)
+			n.items = append(n.items, value)
+		} else {
+			if n.kind == mapNode {
+				return node{}, p.errorf("missing ':' after key")
+			}
+			n.items = append(n.items, item)
+		}
+
+		if p.skipSpace(); p.pos < len(p.s) && p.s[p.pos] == ',' {
+			p.pos++
+		}
+	}
+}
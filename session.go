@@ -0,0 +1,239 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Session is a capture running in the background, from Start until Stop, for
+// output written by goroutines that outlive a single function.
+//
+// While a session runs, other captures wait until it is stopped. Captures made
+// with WithParent(s) nest inside it instead, on any goroutine, so a session
+// started in TestMain or by a parent test can be passed to the tests capturing
+// while it runs.
+type Session struct {
+	c          *Capture
+	hold       *hold
+	holding    bool
+	pipes      []*pipe
+	red        *redirection
+	col        *collector
+	lines      *lineSplitter
+	readErrs   []error
+	concurrent bool
+	wg         sync.WaitGroup
+	start      time.Time
+
+	stop     sync.Once
+	result   Result
+	err      error
+	released sync.Once
+}
+
+// WithCleanup releases what a capture leaves behind when tb and its subtests
+// have completed: it stops a Session and closes a Result captured with TempFile.
+func WithCleanup(tb testing.TB) Option {
+	return func(c *Capture) {
+		c.cleanup = tb
+	}
+}
+
+// WithParent nests the captures made with the Capture inside the session parent:
+// instead of waiting for it to stop, they redirect the streams on top of it, on
+// any goroutine, and restore them to it when they end. Output goes to the
+// capture started last; with Passthrough it is passed on to the one below. Once
+// parent is stopped, captures wait for the lock like any other.
+func WithParent(parent *Session) Option {
+	return func(c *Capture) {
+		c.parent = parent
+	}
+}
+
+// Start redirects the streams c is configured for until Stop is called on the
+// returned session. Output is read while the session runs, even with
+// PipeDirectly and TempFile. WithTimeout and WithRecover do not apply to sessions.
+func (c *Capture) Start() *Session {
+	s, err := c.StartE()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// StartE is like Start but returns an error instead of panicking.
+func (c *Capture) StartE() (*Session, error) {
+	// Nobody else would read the pipes until Stop.
+	capture := c.readingWhileRunning()
+	s, err := capture.start(context.Background(), capture.mode())
+	if err != nil {
+		return nil, err
+	}
+	if tb := capture.cleanup; tb != nil {
+		tb.Helper()
+		tb.Cleanup(func() {
+			if _, err := s.StopE(); err != nil {
+				tb.Errorf("capture: stop session: %v", err)
+			}
+		})
+	}
+	return s, nil
+}
+
+// Start starts a session capturing stdout and stderr, configured by opts.
+func Start(opts ...Option) *Session {
+	return New(opts...).Start()
+}
+
+// StartE is like Start but returns an error instead of panicking.
+func StartE(opts ...Option) (*Session, error) {
+	return New(opts...).StartE()
+}
+
+// start waits for the lock serializing captures, until ctx is done at most, then
+// redirects the streams of m into pipes and starts reading them when c reads
+// concurrently. The lock is held until release.
+func (c *Capture) start(ctx context.Context, m mode) (_ *Session, err error) {
+	if c.method == TempFile {
+		m = mode{streams: m.streams}
+	}
+
+	s := &Session{
+		c:          c,
+		col:        newCollector(c.limit, m.events),
+		lines:      &lineSplitter{fn: c.onLine},
+		concurrent: c.method == PipeWithGoroutine || c.method == DupFD || m.separate,
+	}
+	if err := s.lock(ctx, c.parent); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			s.release()
+		}
+	}()
+
+	s.pipes, err = openPipes(m.selected(), !s.concurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	s.red, err = c.redirect(targets(s.pipes))
+	if err != nil {
+		return nil, err
+	}
+
+	s.readErrs = make([]error, len(s.pipes))
+	if s.concurrent {
+		// Use a goroutine per pipe to read data while f runs.
+		for i := range s.pipes {
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.read(i)
+			}()
+		}
+	}
+
+	s.start = time.Now()
+	return s, nil
+}
+
+func (s *Session) read(i int) {
+	p := s.pipes[i]
+	_, s.readErrs[i] = io.Copy(s.c.writer(s.col, p.streams, s.red, s.lines), p.r)
+}
+
+// Snapshot returns the output captured so far. Output still in the pipes is not
+// included yet.
+func (s *Session) Snapshot() Result {
+	result := s.col.result()
+	result.Duration = time.Since(s.start)
+	result.normalize = s.c.normalize
+	return result
+}
+
+// Stop restores the streams and returns everything captured by the session.
+// Calling Stop again returns the same result.
+func (s *Session) Stop() Result {
+	return must(s.StopE())
+}
+
+// StopE is like Stop but returns an error instead of panicking.
+func (s *Session) StopE() (Result, error) {
+	s.stop.Do(func() {
+		s.result, s.err = s.finish()
+	})
+	return s.result, s.err
+}
+
+func (s *Session) finish() (Result, error) {
+	defer s.release()
+
+	duration := time.Since(s.start)
+	err := s.red.restore()
+	for _, p := range s.pipes {
+		p.w.Close()
+	}
+
+	var kept *resultFile
+	if s.concurrent {
+		s.wg.Wait() // Wait for the goroutines to finish reading.
+	} else if s.c.method == TempFile {
+		kept = s.keep()
+	} else {
+		// Read the backing files from the start.
+		for i := range s.pipes {
+			s.read(i)
+		}
+	}
+
+	for _, tee := range s.c.tees {
+		if flusher, ok := tee.(interface{ Flush() error }); ok {
+			_ = flusher.Flush()
+		}
+	}
+	s.lines.flush()
+
+	for _, readErr := range s.readErrs {
+		if readErr != nil {
+			err = errors.Join(err, fmt.Errorf("%w: %w", ErrRead, readErr))
+		}
+	}
+
+	if limitErr := s.c.limit.err(s.col); limitErr != nil {
+		err = errors.Join(err, limitErr)
+	}
+
+	result := s.col.result()
+	result.Duration = duration
+	result.normalize = s.c.normalize
+	if kept != nil {
+		result.file = kept
+		result.TotalBytes = kept.size
+		if tb := s.c.cleanup; tb != nil {
+			tb.Cleanup(func() {
+				_ = result.Close()
+			})
+		}
+	}
+	return result, err
+}
+
+// release restores the streams if that has not happened yet, closes the pipes
+// and releases the lock serializing captures.
+func (s *Session) release() {
+	s.released.Do(func() {
+		if s.red != nil {
+			_ = s.red.restore()
+			s.red.close()
+		}
+		closePipes(s.pipes)
+		s.unlock()
+	})
+}
@@ -0,0 +1,109 @@
+package capture_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hireza/go-capture"
+)
+
+func TestSession(t *testing.T) {
+	s := capture.Start()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		fmt.Println("from a goroutine")
+	}()
+	wg.Wait()
+	fmt.Fprintln(os.Stderr, "from the test")
+
+	result := s.Stop()
+	if result.Value != "from a goroutine\nfrom the test\n" {
+		t.Errorf("Expected %q but got %q", "from a goroutine\nfrom the test\n", result.Value)
+	}
+
+	if again := s.Stop(); again.Value != result.Value {
+		t.Errorf("Expected a second Stop to return %q but got %q", result.Value, again.Value)
+	}
+}
+
+func TestSessionSnapshot(t *testing.T) {
+	s := capture.Start()
+	defer s.Stop()
+
+	fmt.Println("so far")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(s.Snapshot().Value, "so far") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the snapshot to contain %q but got %q", "so far", s.Snapshot().Value)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSessionNested(t *testing.T) {
+	s := capture.Start()
+
+	inner := capture.New(capture.WithParent(s)).Stdout(func() {
+		fmt.Println("inner")
+	})
+	fmt.Println("outer")
+
+	result := s.Stop()
+	if inner.Value != "inner\n" {
+		t.Errorf("Expected %q but got %q", "inner\n", inner.Value)
+	}
+	if result.Value != "outer\n" {
+		t.Errorf("Expected %q but got %q", "outer\n", result.Value)
+	}
+}
+
+func TestSessionNestedGoroutine(t *testing.T) {
+	s := capture.Start(capture.WithCleanup(t))
+
+	var inner capture.Result
+	t.Run("subtest", func(t *testing.T) {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			inner = capture.New(capture.WithParent(s)).Stdout(func() {
+				fmt.Println("inner")
+			})
+		}()
+		wg.Wait()
+		fmt.Println("outer")
+	})
+
+	result := s.Stop()
+	if inner.Value != "inner\n" {
+		t.Errorf("Expected %q but got %q", "inner\n", inner.Value)
+	}
+	if result.Value != "outer\n" {
+		t.Errorf("Expected %q but got %q", "outer\n", result.Value)
+	}
+}
+
+func TestWithCleanup(t *testing.T) {
+	stdout := os.Stdout
+
+	var s *capture.Session
+	t.Run("session", func(t *testing.T) {
+		s = capture.Start(capture.WithCleanup(t))
+		fmt.Println("stopped by cleanup")
+	})
+
+	if os.Stdout != stdout {
+		t.Errorf("Expected os.Stdout to be restored by the cleanup")
+	}
+	if result := s.Stop(); result.Value != "stopped by cleanup\n" {
+		t.Errorf("Expected %q but got %q", "stopped by cleanup\n", result.Value)
+	}
+}
@@ -0,0 +1,71 @@
+package capture
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// File descriptor of the standard input, redirected by DupFD.
+const stdinFD = 0
+
+// WithStdin feeds the data read from r to os.Stdin while the captured function
+// runs; once r is exhausted os.Stdin reports EOF. r is read by the first capture
+// only, so use WithInput for a Capture that is used several times.
+func WithStdin(r io.Reader) Option {
+	return func(c *Capture) {
+		c.stdin = func() io.Reader {
+			return r
+		}
+	}
+}
+
+// WithInput feeds s to os.Stdin while the captured function runs.
+func WithInput(s string) Option {
+	return func(c *Capture) {
+		c.stdin = func() io.Reader {
+			return strings.NewReader(s)
+		}
+	}
+}
+
+// WithInputLines feeds the lines to os.Stdin while the captured function runs,
+// each terminated by a newline.
+func WithInputLines(lines ...string) Option {
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return WithInput(b.String())
+}
+
+// redirectStdin points os.Stdin, and with DupFD file descriptor 0, at a pipe fed
+// from input. Data left unread when the redirection is closed is discarded.
+// streamsMu must be held.
+func (r *redirection) redirectStdin(method BufferMethod, input io.Reader) error {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPipeCreate, err)
+	}
+	r.files = append(r.files, pr)
+
+	if method == DupFD {
+		if _, err := push(r, stdinFile, pr); err != nil {
+			pw.Close()
+			return err
+		}
+	}
+
+	if _, err := push(r, stdinVar, pr); err != nil {
+		pw.Close()
+		return err
+	}
+
+	go func() {
+		_, _ = io.Copy(pw, input)
+		pw.Close()
+	}()
+	return nil
+}
@@ -0,0 +1,94 @@
+package capture_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hireza/go-capture"
+)
+
+func greet() {
+	fmt.Print("Name: ")
+	var name string
+	if _, err := fmt.Scan(&name); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return
+	}
+	fmt.Printf("Hello, %s!\n", name)
+}
+
+func TestWithInput(t *testing.T) {
+	methods := []capture.BufferMethod{capture.PipeDirectly, capture.PipeWithGoroutine}
+
+	for _, method := range methods {
+		t.Run(fmt.Sprint(method), func(t *testing.T) {
+			stdin := os.Stdin
+
+			output := capture.New(capture.WithMethod(method), capture.WithInput("bob\n")).Output(greet)
+
+			expected := "Name: Hello, bob!\n"
+			if output.Value != expected {
+				t.Errorf("Expected output to be %q, but got %q", expected, output.Value)
+			}
+			if os.Stdin != stdin {
+				t.Error("Expected os.Stdin to be restored")
+			}
+		})
+	}
+}
+
+func TestWithInputReused(t *testing.T) {
+	c := capture.New(capture.WithInput("alice\n"))
+
+	for i := 0; i < 2; i++ {
+		output := c.Stdout(greet)
+
+		expected := "Name: Hello, alice!\n"
+		if output.Value != expected {
+			t.Errorf("Run %d: expected output to be %q, but got %q", i, expected, output.Value)
+		}
+	}
+}
+
+func TestWithInputLines(t *testing.T) {
+	output := capture.New(capture.WithInputLines("one", "two", "three")).Stdout(func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			fmt.Println(strings.ToUpper(scanner.Text()))
+		}
+	})
+
+	expected := "ONE\nTWO\nTHREE\n"
+	if output.Value != expected {
+		t.Errorf("Expected output to be %q, but got %q", expected, output.Value)
+	}
+}
+
+func TestWithStdin(t *testing.T) {
+	output := capture.New(capture.WithStdin(strings.NewReader("data"))).Stdout(func() {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			t.Errorf("ReadAll() error = %v", err)
+		}
+		fmt.Print(len(data))
+	})
+
+	if output.Value != "4" {
+		t.Errorf("Expected output to be %q, but got %q", "4", output.Value)
+	}
+}
+
+func TestWithStdinUnread(t *testing.T) {
+	// Input left unread must not keep the capture from finishing.
+	output := capture.New(capture.WithInput(strings.Repeat("x", 1<<20))).Stdout(func() {
+		fmt.Print("done")
+	})
+
+	if output.Value != "done" {
+		t.Errorf("Expected output to be %q, but got %q", "done", output.Value)
+	}
+}
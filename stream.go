@@ -0,0 +1,102 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Line is a line of output, passed to the function given to StreamLines or WithLineFunc
+// as soon as it has been read.
+type Line struct {
+	// Stream is the stream the line was written to; both streams when they share
+	// a pipe.
+	Stream Stream
+
+	// Text is the line without its trailing newline.
+	Text string
+
+	// Time is when the end of the line was read.
+	Time time.Time
+}
+
+// WithLineFunc calls fn with every line of output as it is read. fn is called
+// from the goroutines reading the output, one line at a time; while it runs, the
+// captured function blocks once the pipe is full. With PipeDirectly the lines are
+// only read once the captured function returns. A last line without a trailing
+// newline is passed to fn when the capture finishes.
+func WithLineFunc(fn func(Line)) Option {
+	return func(c *Capture) {
+		c.onLine = fn
+	}
+}
+
+// StreamLines captures the streams c is configured for like Run, each through its
+// own pipe, calling fn with every line as soon as it is written, so a test can
+// react to output while f is still running.
+func (c *Capture) StreamLines(f func(), fn func(Line)) (Result, error) {
+	capture := c.With(WithLineFunc(fn), WithSeparateStreams()).readingWhileRunning()
+	return capture.capture(context.Background(), f, capture.mode())
+}
+
+// StreamLines captures stdout and stderr, calling fn with every line as soon as it is written.
+func StreamLines(f func(), fn func(Line)) (Result, error) {
+	return defaultCapture.StreamLines(f, fn)
+}
+
+// lineSplitter splits the output of the streams of one capture into lines.
+type lineSplitter struct {
+	mu      sync.Mutex
+	fn      func(Line)
+	pending map[Stream][]byte
+}
+
+// writer returns a writer splitting the output of the streams s.
+func (l *lineSplitter) writer(s Stream) io.Writer {
+	return lineWriter{l: l, s: s}
+}
+
+func (l *lineSplitter) write(s Stream, p []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.pending == nil {
+		l.pending = make(map[Stream][]byte)
+	}
+
+	buf := append(l.pending[s], p...)
+	for {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			break
+		}
+		l.fn(Line{Stream: s, Text: string(buf[:i]), Time: time.Now()})
+		buf = buf[i+1:]
+	}
+	l.pending[s] = buf
+}
+
+// flush passes on the partial lines left at the end of the output.
+func (l *lineSplitter) flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, s := range []Stream{StreamStdout | StreamStderr, StreamStdout, StreamStderr} {
+		if buf := l.pending[s]; len(buf) > 0 {
+			l.fn(Line{Stream: s, Text: string(buf), Time: time.Now()})
+		}
+	}
+	l.pending = nil
+}
+
+type lineWriter struct {
+	l *lineSplitter
+	s Stream
+}
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	w.l.write(w.s, p)
+	return len(p), nil
+}
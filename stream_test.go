@@ -0,0 +1,72 @@
+package capture_test
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hireza/go-capture"
+)
+
+func TestStreamLines(t *testing.T) {
+	listening := make(chan struct{})
+
+	var lines []capture.Line
+	result, err := capture.StreamLines(func() {
+		fmt.Println("listening on :1234")
+		select {
+		case <-listening:
+		case <-time.After(5 * time.Second):
+		}
+		fmt.Fprintln(os.Stderr, "shutting down")
+		fmt.Print("bye")
+	}, func(line capture.Line) {
+		lines = append(lines, line)
+		if line.Text == "listening on :1234" {
+			close(listening)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	var got []string
+	for _, line := range lines {
+		got = append(got, fmt.Sprintf("%v: %s", line.Stream, line.Text))
+	}
+	expected := []string{"stdout: listening on :1234", "stderr: shutting down", "stdout: bye"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q but got %q", expected, got)
+	}
+	if result.Stdout != "listening on :1234\nbye" {
+		t.Errorf("Expected %q but got %q", "listening on :1234\nbye", result.Stdout)
+	}
+}
+
+func TestWithLineFunc(t *testing.T) {
+	methods := []capture.BufferMethod{capture.PipeDirectly, capture.PipeWithGoroutine}
+
+	for _, method := range methods {
+		t.Run(fmt.Sprint(method), func(t *testing.T) {
+			var lines []string
+			c := capture.New(capture.WithMethod(method), capture.WithLineFunc(func(line capture.Line) {
+				lines = append(lines, line.Text)
+			}))
+
+			output := c.Output(func() {
+				fmt.Print("one\ntw")
+				fmt.Print("o\n\nthree")
+			})
+
+			expected := []string{"one", "two", "", "three"}
+			if !reflect.DeepEqual(lines, expected) {
+				t.Errorf("Expected %q but got %q", expected, lines)
+			}
+			if output.Value != "one\ntwo\n\nthree" {
+				t.Errorf("Expected %q but got %q", "one\ntwo\n\nthree", output.Value)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package capture
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// AsSliceStringSep converts the Result to a slice of strings separated by sep,
+// such as the output of strings.Join. Surrounding square brackets are removed
+// and the items are taken as they are, without unquoting them.
+func (o Result) AsSliceStringSep(sep string) ([]string, error) {
+	result, err := decodeStringsSep(o.normalized(), sep)
+	return result, conversionError[[]string](o, err)
+}
+
+// decodeStrings decodes a printed slice of strings: quoted as JSON or %q print
+// it, ["a", "b"] or ["a" "b"], or unquoted as %v prints it, [a b]. Unquoted items
+// are separated by single spaces; since fmt prints empty items and items with
+// spaces the same way, output where that makes a difference is reported as
+// ErrAmbiguous rather than guessed. An item that contains a space itself cannot
+// be told apart from two items, so print such slices with %q.
+func decodeStrings(s string) ([]string, error) {
+	inner, prefixed := strings.CutPrefix(s, "[")
+	inner, suffixed := strings.CutSuffix(inner, "]")
+	if !prefixed || !suffixed {
+		return nil, fmt.Errorf("missing square brackets around %q", s)
+	}
+	if inner == "" {
+		return []string{}, nil
+	}
+
+	if inner[0] == '"' || inner[0] == '`' {
+		return decodeQuotedStrings(s)
+	}
+
+	items := strings.Split(inner, " ")
+	for i, item := range items {
+		if item == "" {
+			return items, fmt.Errorf("%w: item %d of %q is empty or starts or ends with a space", ErrAmbiguous, i, s)
+		}
+		if item[0] == '"' || item[0] == '`' {
+			return items, fmt.Errorf("%w: %q mixes quoted and unquoted items", ErrAmbiguous, s)
+		}
+	}
+	return items, nil
+}
+
+// decodeQuotedStrings decodes a list of quoted strings.
+func decodeQuotedStrings(s string) ([]string, error) {
+	n, err := parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]string, len(n.items))
+	for i, item := range n.items {
+		if item.kind != quotedNode {
+			return items, fmt.Errorf("%w: %q mixes quoted and unquoted items", ErrAmbiguous, s)
+		}
+		items[i] = item.text
+	}
+	return items, nil
+}
+
+// decodeStringsSep splits s at sep, after removing the square brackets around it.
+func decodeStringsSep(s, sep string) ([]string, error) {
+	if sep == "" {
+		return nil, fmt.Errorf("empty separator")
+	}
+	if inner, ok := strings.CutPrefix(s, "["); ok {
+		if inner, ok = strings.CutSuffix(inner, "]"); ok {
+			s = inner
+		}
+	}
+	if s == "" {
+		return []string{}, nil
+	}
+	return strings.Split(s, sep), nil
+}
+
+// goSyntax reports whether s starts with a Go type, as %#v prints a slice of
+// strings: []string{"a b", "c"} or []string(nil). Such output is quoted and left
+// to the general parser.
+func goSyntax(s string) bool {
+	p := &parser{s: s}
+	return p.literalType()
+}
+
+// isStringSlice reports whether t is a slice of strings, which is decoded by decodeStrings.
+func isStringSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String &&
+		!reflect.PointerTo(t.Elem()).Implements(textUnmarshalerType)
+}
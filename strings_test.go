@@ -0,0 +1,89 @@
+package capture_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hireza/go-capture"
+)
+
+func TestAsSliceStringFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		value  []string
+	}{
+		{name: "v", format: "%v", value: []string{"a", "b", "c"}},
+		{name: "q", format: "%q", value: []string{"a b", "", `"quoted"`}},
+		{name: "json", format: `["%s", "%s"]`, value: []string{"a b", "c"}},
+		{name: "empty", format: "%v", value: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := capture.Stdout(func() {
+				if strings.HasPrefix(test.format, "[") {
+					fmt.Printf(test.format+"\n", test.value[0], test.value[1])
+				} else {
+					fmt.Printf(test.format+"\n", test.value)
+				}
+			})
+
+			got, err := output.AsSliceString()
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if !reflect.DeepEqual(got, test.value) {
+				t.Errorf("Expected %q but got %q", test.value, got)
+			}
+		})
+	}
+}
+
+func TestAsSliceStringAmbiguous(t *testing.T) {
+	tests := [][]string{
+		{"a", "", "b"},
+		{" a", "b"},
+		{"a", "b "},
+		{"a", `"b"`},
+	}
+
+	for _, value := range tests {
+		t.Run(fmt.Sprintf("%q", value), func(t *testing.T) {
+			output := capture.Stdout(func() {
+				fmt.Println(value)
+			})
+
+			if _, err := output.AsSliceString(); !errors.Is(err, capture.ErrAmbiguous) {
+				t.Errorf("Expected ErrAmbiguous for %q but got %v", output.Value, err)
+			}
+		})
+	}
+}
+
+func TestAsSliceStringSep(t *testing.T) {
+	tests := []struct {
+		input string
+		sep   string
+		want  []string
+	}{
+		{input: "a,b,,c", sep: ",", want: []string{"a", "b", "", "c"}},
+		{input: "[a b | c]", sep: " | ", want: []string{"a b", "c"}},
+		{input: "[]", sep: ",", want: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got, err := capture.Result{Value: test.input}.AsSliceStringSep(test.sep)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
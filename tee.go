@@ -0,0 +1,64 @@
+package capture
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// WithTee also writes everything captured to w as it is read, so the output stays
+// visible while it is captured. With PipeDirectly the output is only read, and
+// so written to w, once the captured function returns; use PipeWithGoroutine or
+// DupFD to see it live. If w has a Flush() error method, it is called when the
+// capture finishes. WithTee may be given several times to write to several
+// writers. To pass the output on to the original os.Stdout and
+// os.Stderr, keeping the streams apart, use WithPassthrough.
+func WithTee(w io.Writer) Option {
+	return func(c *Capture) {
+		c.tees = append(slices.Clip(c.tees), w)
+	}
+}
+
+// LogWriter returns a writer that logs every line written to it with tb.Log.
+// Used with WithTee, captured output shows up in the test log, and so in the
+// output of go test -v or of a failing test.
+func LogWriter(tb testing.TB) io.Writer {
+	return &logWriter{tb: tb}
+}
+
+// logWriter logs complete lines, keeping a trailing partial line until Flush.
+type logWriter struct {
+	mu  sync.Mutex
+	tb  testing.TB
+	buf []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.tb.Log(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush logs the trailing partial line, if any.
+func (w *logWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.tb.Log(string(w.buf))
+		w.buf = nil
+	}
+	return nil
+}
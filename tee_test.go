@@ -0,0 +1,89 @@
+package capture_test
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hireza/go-capture"
+)
+
+func TestWithTee(t *testing.T) {
+	methods := []capture.BufferMethod{capture.PipeDirectly, capture.PipeWithGoroutine}
+
+	for _, method := range methods {
+		t.Run(fmt.Sprint(method), func(t *testing.T) {
+			var tee bytes.Buffer
+			output := capture.New(capture.WithMethod(method), capture.WithTee(&tee)).Output(func() {
+				fmt.Println("Hello, stdout!")
+				fmt.Fprintln(os.Stderr, "Hello, stderr!")
+			})
+
+			if tee.String() != output.Value || output.Value != "Hello, stdout!\nHello, stderr!\n" {
+				t.Errorf("Expected tee %q to match output %q", tee.String(), output.Value)
+			}
+		})
+	}
+}
+
+func TestWithTeeFlush(t *testing.T) {
+	var tee bytes.Buffer
+	buffered := bufio.NewWriter(&tee)
+
+	capture.New(capture.WithTee(buffered)).Stdout(func() {
+		fmt.Print("buffered")
+	})
+
+	if tee.String() != "buffered" {
+		t.Errorf("Expected the tee to be flushed, but got %q", tee.String())
+	}
+}
+
+func TestWithTeeLive(t *testing.T) {
+	var tee bytes.Buffer
+
+	in := capture.New(capture.WithTee(&tee)).Interact(func() {
+		fmt.Println("first")
+		var s string
+		_, _ = fmt.Scanln(&s)
+	})
+	if err := in.Expect("first\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	if tee.String() != "first\n" {
+		t.Errorf("Expected tee to receive %q, but got %q", "first\n", tee.String())
+	}
+}
+
+// recordingTB records the messages logged with Log.
+type recordingTB struct {
+	testing.TB
+	logs []string
+}
+
+func (tb *recordingTB) Log(args ...any) {
+	tb.logs = append(tb.logs, fmt.Sprint(args...))
+}
+
+func TestLogWriter(t *testing.T) {
+	tb := &recordingTB{TB: t}
+
+	capture.New(capture.WithMethod(capture.PipeWithGoroutine), capture.WithTee(capture.LogWriter(tb))).Stdout(func() {
+		fmt.Println("line one")
+		fmt.Print("line ")
+		fmt.Println("two")
+		fmt.Print("partial")
+	})
+
+	expected := []string{"line one", "line two", "partial"}
+	if !reflect.DeepEqual(tb.logs, expected) {
+		t.Errorf("Expected logs %q, but got %q", expected, tb.logs)
+	}
+}
@@ -0,0 +1,109 @@
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// resultFile is the temporary file holding the output captured with TempFile.
+type resultFile struct {
+	name string
+	size int64
+}
+
+// keep takes the temporary file of the session out of its pipes, so it is not
+// removed when the session is released.
+func (s *Session) keep() *resultFile {
+	p := s.pipes[0]
+	f := &resultFile{name: p.name}
+	if info, err := os.Stat(p.name); err == nil {
+		f.size = info.Size()
+	}
+	p.name = ""
+	return f
+}
+
+// Open returns a reader over everything captured. For output captured with
+// TempFile it opens the temporary file, which must not have been closed with
+// Close; otherwise it reads Value.
+func (o Result) Open() (io.ReadSeekCloser, error) {
+	if o.file == nil {
+		return nopCloser{strings.NewReader(o.Value)}, nil
+	}
+	return os.Open(o.file.name)
+}
+
+// Load returns a copy of the Result with Value read from the temporary file of
+// output captured with TempFile, so it can be converted like any other Result.
+// The temporary file is kept until Close is called.
+func (o Result) Load() (Result, error) {
+	if o.file == nil {
+		return o, nil
+	}
+	data, err := os.ReadFile(o.file.name)
+	if err != nil {
+		return o, err
+	}
+	o.Value = string(data)
+	return o, nil
+}
+
+// Index returns the offset of the first occurrence of s in everything captured,
+// or -1 if it does not occur. Output captured with TempFile is searched without
+// loading it into memory.
+func (o Result) Index(s string) (int64, error) {
+	r, err := o.Open()
+	if err != nil {
+		return -1, err
+	}
+	defer r.Close()
+
+	if s == "" {
+		return 0, nil
+	}
+
+	// Keep the last len(s)-1 bytes of every chunk, to find s across chunks.
+	buf := make([]byte, max(64<<10, 2*len(s)))
+	var offset int64
+	kept := 0
+	for {
+		n, err := r.Read(buf[kept:])
+		n += kept
+		if i := bytes.Index(buf[:n], []byte(s)); i >= 0 {
+			return offset + int64(i), nil
+		}
+		if err == io.EOF {
+			return -1, nil
+		}
+		if err != nil {
+			return -1, err
+		}
+
+		kept = min(n, len(s)-1)
+		offset += int64(n - kept)
+		copy(buf, buf[n-kept:n])
+	}
+}
+
+// Close removes the temporary file of output captured with TempFile. It does
+// nothing for other results, and may be called more than once.
+func (o Result) Close() error {
+	if o.file == nil {
+		return nil
+	}
+	if err := os.Remove(o.file.name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
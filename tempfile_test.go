@@ -0,0 +1,95 @@
+package capture_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hireza/go-capture"
+)
+
+func TestTempFile(t *testing.T) {
+	result, err := capture.New(capture.WithMethod(capture.TempFile)).Run(func() {
+		fmt.Println(strings.Repeat("x", 100<<10))
+		fmt.Fprint(os.Stderr, "needle")
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	defer result.Close()
+
+	if result.Value != "" || result.TotalBytes != 100<<10+7 {
+		t.Errorf("Expected %d bytes kept in the file but got %d, value of %d bytes", 100<<10+7, result.TotalBytes, len(result.Value))
+	}
+
+	index, err := result.Index("needle")
+	if err != nil || index != 100<<10+1 {
+		t.Errorf("Expected needle at %d but got %d, %v", 100<<10+1, index, err)
+	}
+	if index, _ := result.Index("missing"); index != -1 {
+		t.Errorf("Expected -1 for a missing string but got %d", index)
+	}
+
+	r, err := result.Open()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	defer r.Close()
+	if _, err := r.Seek(-6, io.SeekEnd); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if data, _ := io.ReadAll(r); string(data) != "needle" {
+		t.Errorf("Expected %q but got %q", "needle", data)
+	}
+
+	loaded, err := result.Load()
+	if err != nil || !strings.HasSuffix(loaded.Value, "x\nneedle") {
+		t.Errorf("Expected the loaded value to end with %q, got error %v", "x\nneedle", err)
+	}
+}
+
+func TestTempFileClose(t *testing.T) {
+	result := capture.UseMethod(capture.TempFile).Stdout(func() {
+		fmt.Print("42")
+	})
+
+	loaded, _ := result.Load()
+	if v, err := loaded.AsInt(); err != nil || v != 42 {
+		t.Errorf("Expected 42 but got %d, %v", v, err)
+	}
+
+	if err := result.Close(); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+	if _, err := result.Open(); err == nil {
+		t.Errorf("Expected the file to be removed")
+	}
+	if err := result.Close(); err != nil {
+		t.Errorf("Expected closing again to succeed but got %v", err)
+	}
+}
+
+func TestTempFileCleanup(t *testing.T) {
+	var result capture.Result
+	t.Run("capture", func(t *testing.T) {
+		result = capture.New(capture.WithMethod(capture.TempFile), capture.WithCleanup(t)).Stdout(func() {
+			fmt.Print("removed")
+		})
+	})
+
+	if _, err := result.Open(); err == nil {
+		t.Errorf("Expected the file to be removed by the cleanup")
+	}
+}
+
+func TestResultIndexInMemory(t *testing.T) {
+	result := capture.Stdout(func() {
+		fmt.Print("hello, world")
+	})
+
+	if index, err := result.Index("world"); err != nil || index != 7 {
+		t.Errorf("Expected 7 but got %d, %v", index, err)
+	}
+}